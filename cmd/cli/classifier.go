@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/caner-cetin/seer/internal"
+	"github.com/caner-cetin/seer/pkg/detect"
+	"github.com/fatih/color"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+type classifierTrainConfig struct {
+	Samples string
+	Output  string
+}
+
+var (
+	classifierCmd = &cobra.Command{
+		Use:   "classifier",
+		Short: "manage the naive bayes model detection falls back to",
+	}
+	classifierTrainCmd = &cobra.Command{
+		Use:   "train --samples <dir> [--output file]",
+		Short: "train the classifier from a samples directory with one directory per language",
+		Long: "train the classifier from a samples directory with one directory per language, named after its fs_name or name.\n" +
+			"the samples directory of a Linguist checkout has this layout.",
+		Run: WrapCommandWithResources(trainClassifier, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
+	}
+	classifierTrainCfg classifierTrainConfig
+)
+
+func getClassifierCmd() *cobra.Command {
+	classifierTrainCmd.Flags().StringVar(&classifierTrainCfg.Samples, "samples", "", "samples directory, such as the samples directory of a Linguist checkout")
+	classifierTrainCmd.Flags().StringVarP(&classifierTrainCfg.Output, "output", "o", classifierPath(), "file to write the model to, defaults to linguist.classifier_path from the config")
+	if err := classifierTrainCmd.MarkFlagRequired("samples"); err != nil {
+		log.Fatal().Err(err).Msg("failed to mark samples flag required")
+	}
+	classifierCmd.AddCommand(classifierTrainCmd)
+	return classifierCmd
+}
+
+func trainClassifier(cmd *cobra.Command, args []string) {
+	app := GetApp(cmd).(internal.AppCtx)
+	if classifierTrainCfg.Output == "" {
+		log.Error().Msg("no user cache directory, give the model path with --output")
+		return
+	}
+	languages, err := app.DB.GetLanguages(cmd.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get languages")
+		return
+	}
+	classifier, summary, err := detect.Train(classifierTrainCfg.Samples, languages)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to train classifier")
+		return
+	}
+	if err := classifier.Save(classifierTrainCfg.Output); err != nil {
+		log.Error().Err(err).Msg("failed to save classifier")
+		return
+	}
+	fmt.Printf("classifier: %d samples of %d languages, written to %s\n", summary.Samples, summary.Languages, classifierTrainCfg.Output)
+	for _, dir := range summary.UnknownDirs {
+		color.Yellow("  ! %s: no language has this fs_name or name", dir)
+	}
+}
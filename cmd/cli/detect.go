@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/caner-cetin/seer/internal"
+	"github.com/caner-cetin/seer/pkg/analyze"
+	"github.com/caner-cetin/seer/pkg/detect"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+type detectConfig struct {
+	Format     string
+	Classifier string
+}
+
+var (
+	detectCmd = &cobra.Command{
+		Use:   "detect [--format table|json] <file>...",
+		Short: "detect the language of files from the languages table and .gitattributes linguist overrides",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   WrapCommandWithResources(detectLanguages, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
+	}
+	detectCfg detectConfig
+)
+
+func getDetectCmd() *cobra.Command {
+	detectCmd.Flags().StringVar(&detectCfg.Format, "format", "table", "output format, table or json")
+	detectCmd.Flags().StringVar(&detectCfg.Classifier, "classifier", classifierPath(), "model written by seer classifier train, detection runs without the classifier when the default model does not exist")
+	return detectCmd
+}
+
+func detectLanguages(cmd *cobra.Command, args []string) {
+	app := GetApp(cmd).(internal.AppCtx)
+	if detectCfg.Format != "table" && detectCfg.Format != "json" {
+		log.Error().Str("format", detectCfg.Format).Msg("unknown output format")
+		return
+	}
+	detector, err := newDetector(cmd, app, detectCfg.Classifier)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to build language detector")
+		return
+	}
+	data, err := analyze.Load(cmd.Context(), app.DB)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load path rules")
+		return
+	}
+	rules := analyze.NewRules(data)
+	// files of the same work tree share an analyzer, so each .gitattributes is read once
+	analyzers := make(map[string]*analyze.Analyzer)
+	files := make([]analyze.File, 0, len(args))
+	for _, path := range args {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("failed to read file")
+			continue
+		}
+		root, err := analyze.Root(path)
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("failed to find work tree")
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("failed to resolve path")
+			continue
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("failed to resolve path")
+			continue
+		}
+		analyzer, ok := analyzers[root]
+		if !ok {
+			analyzer = analyze.New(root, detector, rules)
+			analyzers[root] = analyzer
+		}
+		file := analyzer.File(filepath.ToSlash(rel), content)
+		// report the path as it was given rather than relative to the work tree
+		file.Path = path
+		files = append(files, file)
+	}
+
+	if detectCfg.Format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(files); err != nil {
+			log.Error().Err(err).Msg("failed to encode detect results")
+		}
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tLANGUAGE\tSTRATEGY\tREASON\tOTHER CANDIDATES\tMARKS")
+	for _, file := range files {
+		if file.Content.Binary && file.Candidates == nil {
+			fmt.Fprintf(w, "%s\t-\tbinary\t%s\t-\t%s\n", file.Path, file.Content.MIME, marks(file))
+			continue
+		}
+		best, ok := file.Language()
+		if !ok {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t%s\n", file.Path, marks(file))
+			continue
+		}
+		others := make([]string, 0, len(file.Candidates)-1)
+		for _, c := range file.Candidates[1:] {
+			others = append(others, c.Name)
+		}
+		other := "-"
+		if len(others) > 0 {
+			other = strings.Join(others, ", ")
+		}
+		reason := best.Reason
+		if best.Probability > 0 {
+			reason = fmt.Sprintf("%s (%.1f%%)", reason, best.Probability*100)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", file.Path, best.Name, best.Strategy, reason, other, marks(file))
+	}
+	if err := w.Flush(); err != nil {
+		log.Error().Err(err).Msg("failed to write detect results")
+	}
+}
+
+// marks lists the vendored, generated and documentation marks of file, with the attribute behind each
+// one an attribute decided
+func marks(file analyze.File) string {
+	var set []string
+	for _, m := range []struct {
+		name string
+		mark analyze.Mark
+	}{
+		{"vendored", file.Vendored},
+		{"generated", file.Generated},
+		{"documentation", file.Documentation},
+	} {
+		if !m.mark.Set {
+			continue
+		}
+		if strings.HasPrefix(m.mark.Source, "linguist-") {
+			set = append(set, fmt.Sprintf("%s (%s)", m.name, m.mark.Source))
+		} else {
+			set = append(set, m.name)
+		}
+	}
+	if len(set) == 0 {
+		return "-"
+	}
+	return strings.Join(set, ", ")
+}
+
+// classifierPath returns linguist.classifier_path from the config, or detect.DefaultClassifierPath when it is unset
+func classifierPath() string {
+	if cfg.Linguist.ClassifierPath != "" {
+		return cfg.Linguist.ClassifierPath
+	}
+	return detect.DefaultClassifierPath()
+}
+
+// newDetector builds a detector from the database and the classifier model, a missing model is only an error
+// when --classifier was given explicitly
+func newDetector(cmd *cobra.Command, app internal.AppCtx, classifier string) (*detect.Detector, error) {
+	data, err := detect.Load(cmd.Context(), app.DB)
+	if err != nil {
+		return nil, err
+	}
+	if classifier != "" {
+		data.Classifier, err = detect.LoadClassifier(classifier)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) || cmd.Flags().Changed("classifier") {
+				return nil, err
+			}
+			log.Debug().Str("classifier", classifier).Msg("no classifier model, run seer classifier train to build one")
+		}
+	}
+	return detect.NewFromData(data), nil
+}
@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/caner-cetin/seer/internal"
+	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+type languagesRollbackConfig struct {
+	To int32
+}
+
+type languagesExportConfig struct {
+	Format string
+	Output string
+}
+
+type languagesImportConfig struct {
+	Format string
+}
+
+type languagesDiffConfig struct {
+	Source   string
+	Overlays []string
+	Format   string
+	Strict   bool
+}
+
+// exit codes of languages diff, following diff(1)
+const (
+	diffExitDifferent = 1
+	diffExitTrouble   = 2
+)
+
+var (
+	languagesCmd = &cobra.Command{
+		Use:   "languages",
+		Short: "inspect and manage the languages table",
+	}
+	languagesRunsCmd = &cobra.Command{
+		Use:   "runs",
+		Short: "list ingest runs, newest first",
+		Run:   WrapCommandWithResources(listIngestRuns, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
+	}
+	languagesRollbackCmd = &cobra.Command{
+		Use:   "rollback --to <run>",
+		Short: "restore the languages table to the state left by an earlier ingest run",
+		Run:   WrapCommandWithResources(rollbackLanguages, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
+	}
+	languagesDiffCmd = &cobra.Command{
+		Use:   "diff [--source path] [--format table|json]",
+		Short: "compare a candidate languages.yml against the languages table without touching it",
+		Long: "compare a candidate languages.yml against the languages table without touching it.\n" +
+			"exits with 1 when anything differs and 2 when the comparison could not be made.",
+		// trouble until the comparison is made, so a failure before diffLanguages runs, such as the database
+		// being unreachable, does not exit 0 and read as no differences
+		PreRun: func(cmd *cobra.Command, args []string) { exitCode = diffExitTrouble },
+		Run:    WrapCommandWithResources(diffLanguages, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
+	}
+	languagesExportCmd = &cobra.Command{
+		Use:   "export [--format yaml|json|csv] [--output file]",
+		Short: "write the languages table as languages.yml, json or csv",
+		Long: "write the languages table as languages.yml, json or csv.\n" +
+			"yaml output is in the Linguist languages.yml shape and can be fed back through migrate --linguist-language-remote-path.",
+		Run: WrapCommandWithResources(exportLanguages, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
+	}
+	languagesImportCmd = &cobra.Command{
+		Use:   "import --format tokei|scc <file>",
+		Short: "import comment and string syntax from a tokei or scc languages.json",
+		Long: "import comment and string syntax from a tokei or scc languages.json.\n" +
+			"entries are mapped onto the languages table by name, alias or extension, entries that match no single language are listed.",
+		Args: cobra.ExactArgs(1),
+		Run:  WrapCommandWithResources(importLanguageCatalog, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
+	}
+	languagesRollbackCfg languagesRollbackConfig
+	languagesDiffCfg     languagesDiffConfig
+	languagesExportCfg   languagesExportConfig
+	languagesImportCfg   languagesImportConfig
+)
+
+func getLanguagesCmd() *cobra.Command {
+	languagesRollbackCmd.Flags().Int32Var(&languagesRollbackCfg.To, "to", 0, "id of the ingest run to restore, as listed by seer languages runs")
+	if err := languagesRollbackCmd.MarkFlagRequired("to"); err != nil {
+		log.Fatal().Err(err).Msg("failed to mark to flag required")
+	}
+	languagesDiffCmd.Flags().StringVar(&languagesDiffCfg.Source, "source", db.DefaultLanguagesPath, "candidate languages.yml, accepts the same forms as migrate --linguist-language-remote-path")
+	languagesDiffCmd.Flags().StringSliceVar(&languagesDiffCfg.Overlays, "overlay", cfg.Linguist.Overlays, "languages.yml overlay merged over the candidate, repeatable. defaults to linguist.overlays from the config")
+	languagesDiffCmd.Flags().StringVar(&languagesDiffCfg.Format, "format", "table", "output format, table or json")
+	languagesDiffCmd.Flags().BoolVar(&languagesDiffCfg.Strict, "strict", false, "fail when any candidate entry is invalid, instead of skipping it")
+	languagesExportCmd.Flags().StringVar(&languagesExportCfg.Format, "format", db.ExportYAML, "output format, one of "+strings.Join(db.ExportFormats, ", "))
+	languagesExportCmd.Flags().StringVarP(&languagesExportCfg.Output, "output", "o", "", "file to write, stdout when empty")
+	languagesImportCmd.Flags().StringVar(&languagesImportCfg.Format, "format", "", "catalog format, one of "+strings.Join(db.Catalogs, ", "))
+	languagesCmd.AddCommand(languagesDiffCmd)
+	languagesCmd.AddCommand(languagesExportCmd)
+	languagesCmd.AddCommand(languagesImportCmd)
+	languagesCmd.AddCommand(languagesRunsCmd)
+	languagesCmd.AddCommand(languagesRollbackCmd)
+	return languagesCmd
+}
+
+func listIngestRuns(cmd *cobra.Command, args []string) {
+	app := GetApp(cmd).(internal.AppCtx)
+	runs, err := app.DB.ListIngestRuns(cmd.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list ingest runs")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tFINISHED\tADDED\tCHANGED\tREMOVED\tTOTAL\tSHA256\tSOURCE")
+	for _, run := range runs {
+		finished := "-"
+		if run.FinishedAt.Valid {
+			finished = run.FinishedAt.Time.Local().Format(time.DateTime)
+		}
+		sha := "-"
+		if run.Sha256.Valid {
+			sha = run.Sha256.String[:12]
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			run.ID, finished, run.AddedCount, run.ChangedCount, run.RemovedCount, run.TotalCount, sha, run.Source)
+	}
+	if err := w.Flush(); err != nil {
+		log.Error().Err(err).Msg("failed to write ingest runs")
+	}
+}
+
+func rollbackLanguages(cmd *cobra.Command, args []string) {
+	app := GetApp(cmd).(internal.AppCtx)
+	var summary *db.LanguageSyncSummary
+	err := db.WithAdvisoryLock(cmd.Context(), app.Conn, db.LanguageSyncLockKey, func() (err error) {
+		summary, err = db.RollbackLanguages(cmd.Context(), app.Conn, app.DB, languagesRollbackCfg.To)
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Int32("to", languagesRollbackCfg.To).Msg("failed to roll back languages")
+		return
+	}
+	printLanguageSyncSummary(summary)
+}
+
+func diffLanguages(cmd *cobra.Command, args []string) {
+	app := GetApp(cmd).(internal.AppCtx)
+	if languagesDiffCfg.Format != "table" && languagesDiffCfg.Format != "json" {
+		log.Error().Str("format", languagesDiffCfg.Format).Msg("unknown output format")
+		return
+	}
+	candidate, _, err := db.LoadLanguages(cmd.Context(), db.IngestOptions{
+		Path:     languagesDiffCfg.Source,
+		Overlays: languagesDiffCfg.Overlays,
+		Load: db.LoadOptions{
+			Strict: languagesDiffCfg.Strict,
+			Fetch:  fetchOptions(),
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load candidate languages")
+		return
+	}
+	current, err := app.DB.GetLanguages(cmd.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get languages")
+		return
+	}
+	changes := db.DiffLanguages(current, candidate.ToPgType(), db.SyncOptions{Overlays: languagesDiffCfg.Overlays})
+
+	switch languagesDiffCfg.Format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if changes == nil {
+			changes = []db.LanguageChange{}
+		}
+		if err := enc.Encode(changes); err != nil {
+			log.Error().Err(err).Msg("failed to encode language diff")
+			return
+		}
+	default:
+		if err := printLanguageChanges(changes); err != nil {
+			log.Error().Err(err).Msg("failed to write language diff")
+			return
+		}
+	}
+	exitCode = 0
+	if len(changes) > 0 {
+		exitCode = diffExitDifferent
+	}
+}
+
+func exportLanguages(cmd *cobra.Command, args []string) {
+	app := GetApp(cmd).(internal.AppCtx)
+	if !slices.Contains(db.ExportFormats, languagesExportCfg.Format) {
+		log.Error().Str("format", languagesExportCfg.Format).Msg("unknown output format")
+		return
+	}
+	languages, err := app.DB.GetLanguages(cmd.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get languages")
+		return
+	}
+	if languagesExportCfg.Output == "" {
+		if err := db.ExportLanguages(os.Stdout, languages, languagesExportCfg.Format); err != nil {
+			log.Error().Err(err).Msg("failed to export languages")
+		}
+		return
+	}
+	f, err := os.Create(languagesExportCfg.Output)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create export file")
+		return
+	}
+	if err := db.ExportLanguages(f, languages, languagesExportCfg.Format); err != nil {
+		f.Close()
+		log.Error().Err(err).Str("output", languagesExportCfg.Output).Msg("failed to export languages")
+		return
+	}
+	// a failed close may lose buffered writes, so the export is not done until it succeeds
+	if err := f.Close(); err != nil {
+		log.Error().Err(err).Str("output", languagesExportCfg.Output).Msg("failed to close export file")
+		return
+	}
+	log.Info().Int("languages", len(languages)).Str("output", languagesExportCfg.Output).Msg("exported languages")
+}
+
+func importLanguageCatalog(cmd *cobra.Command, args []string) {
+	app := GetApp(cmd).(internal.AppCtx)
+	if !slices.Contains(db.Catalogs, languagesImportCfg.Format) {
+		log.Error().Str("format", languagesImportCfg.Format).Msg("unknown catalog format")
+		return
+	}
+	summary, err := db.ImportCatalog(cmd.Context(), app.Conn, args[0], languagesImportCfg.Format)
+	if err != nil {
+		log.Error().Err(err).Str("file", args[0]).Msg("failed to import language catalog")
+		return
+	}
+	fmt.Printf("%s: %d languages matched, %d unmatched\n", summary.Catalog, len(summary.Matched), len(summary.Unmatched))
+	if len(summary.Unmatched) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UNMATCHED\tEXTENSIONS\tREASON")
+	for _, u := range summary.Unmatched {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", u.Entry.Name, strings.Join(u.Entry.Extensions, ","), u.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		log.Error().Err(err).Msg("failed to write unmatched catalog entries")
+	}
+}
+
+// printLanguageChanges writes one row per changed field, and one row per added or removed language
+func printLanguageChanges(changes []db.LanguageChange) error {
+	if len(changes) == 0 {
+		fmt.Println("no differences")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LANGUAGE_ID\tNAME\tCHANGE\tFIELD\tDETAIL")
+	for _, change := range changes {
+		if len(change.Fields) == 0 {
+			fmt.Fprintf(w, "%d\t%s\t%s\t\t\n", change.LanguageID, change.Name, change.Kind)
+			continue
+		}
+		for _, field := range change.Fields {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", change.LanguageID, change.Name, change.Kind, field.Field, describeFieldChange(field))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush table: %w", err)
+	}
+	return nil
+}
+
+func describeFieldChange(field db.FieldChange) string {
+	if field.Added != nil || field.Removed != nil {
+		var parts []string
+		for _, v := range field.Added {
+			parts = append(parts, "+"+v)
+		}
+		for _, v := range field.Removed {
+			parts = append(parts, "-"+v)
+		}
+		return strings.Join(parts, " ")
+	}
+	return fmt.Sprintf("%s -> %s", describeValue(field.Old), describeValue(field.New))
+}
+
+func describeValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "(null)"
+	case string:
+		return strconv.Quote(v)
+	case []string:
+		return "[" + strings.Join(v, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
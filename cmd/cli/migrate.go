@@ -1,14 +1,26 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/caner-cetin/seer/internal"
 	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/fatih/color"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
 type migrateConfig struct {
-	LinguistLanguageRemotePath string
+	LinguistLanguageRemotePath      string
+	LinguistHeuristicsRemotePath    string
+	LinguistVendorRemotePath        string
+	LinguistDocumentationRemotePath string
+	GeneratedRulesPath              string
+	LinguistGrammarsRemotePath      string
+	Overlays                        []string
+	Strict                          bool
+	Embedded                        bool
 }
 
 var (
@@ -17,43 +29,163 @@ var (
 		Run: WrapCommandWithResources(migrate, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
 	}
 	migrateCfg migrateConfig
+	noCache    bool
 )
 
 func getMigrateCmd() *cobra.Command {
 	migrateCmd.PersistentFlags().StringVar(
 		&migrateCfg.LinguistLanguageRemotePath,
 		"linguist-language-remote-path",
-		"https://raw.githubusercontent.com/github/linguist/master/lib/linguist/languages.yml",
-		"remote path to linguist languages.yml",
+		db.DefaultLanguagesPath,
+		"path to linguist languages.yml: http(s) or file:// url, local path, - for stdin or embedded for the bundled snapshot",
+	)
+	migrateCmd.PersistentFlags().StringVar(
+		&migrateCfg.LinguistHeuristicsRemotePath,
+		"linguist-heuristics-remote-path",
+		db.DefaultHeuristicsPath,
+		"path to linguist heuristics.yml, accepts the same forms as --linguist-language-remote-path",
+	)
+	migrateCmd.PersistentFlags().StringVar(
+		&migrateCfg.LinguistVendorRemotePath,
+		"linguist-vendor-remote-path",
+		db.DefaultVendorPath,
+		"path to linguist vendor.yml, accepts the same forms as --linguist-language-remote-path",
+	)
+	migrateCmd.PersistentFlags().StringVar(
+		&migrateCfg.LinguistDocumentationRemotePath,
+		"linguist-documentation-remote-path",
+		db.DefaultDocumentationPath,
+		"path to linguist documentation.yml, accepts the same forms as --linguist-language-remote-path",
+	)
+	migrateCmd.PersistentFlags().StringVar(
+		&migrateCfg.GeneratedRulesPath,
+		"generated-rules-path",
+		db.DefaultGeneratedPath,
+		"path to the generated file rules, embedded serves the rules bundled with seer",
+	)
+	migrateCmd.PersistentFlags().StringVar(
+		&migrateCfg.LinguistGrammarsRemotePath,
+		"linguist-grammars-remote-path",
+		db.DefaultGrammarsPath,
+		"path to linguist grammars.yml, accepts the same forms as --linguist-language-remote-path except embedded. "+
+			"languages.yml entries whose tm_scope no grammar provides are reported, and fail the sync with --strict",
+	)
+	migrateCmd.PersistentFlags().StringSliceVar(
+		&migrateCfg.Overlays,
+		"overlay",
+		cfg.Linguist.Overlays,
+		"organization specific languages.yml overlay merged over upstream, repeatable. defaults to linguist.overlays from the config",
+	)
+	migrateCmd.PersistentFlags().BoolVar(
+		&migrateCfg.Strict,
+		"strict",
+		false,
+		"abort the sync when any languages.yml entry is invalid or has a warning such as an unknown tm_scope, instead of skipping or reporting it",
+	)
+	migrateCmd.PersistentFlags().BoolVar(
+		&migrateCfg.Embedded,
+		"embedded",
+		false,
+		"read every linguist source not given explicitly from the snapshots bundled with seer, for hosts without network access. "+
+			"grammars.yml has no snapshot, so grammars are left alone unless --linguist-grammars-remote-path is given",
 	)
 	return migrateCmd
 }
 
+// linguistOptions returns the linguist sources to ingest. With --embedded every source flag left unset reads the
+// bundled snapshot, except grammars.yml which has none and is skipped.
+func linguistOptions(cmd *cobra.Command) db.LinguistOptions {
+	opts := db.LinguistOptions{
+		LanguagesPath:     migrateCfg.LinguistLanguageRemotePath,
+		Overlays:          migrateCfg.Overlays,
+		Strict:            migrateCfg.Strict,
+		HeuristicsPath:    migrateCfg.LinguistHeuristicsRemotePath,
+		VendorPath:        migrateCfg.LinguistVendorRemotePath,
+		DocumentationPath: migrateCfg.LinguistDocumentationRemotePath,
+		GeneratedPath:     migrateCfg.GeneratedRulesPath,
+		GrammarsPath:      migrateCfg.LinguistGrammarsRemotePath,
+		Fetch:             fetchOptions(),
+	}
+	if !migrateCfg.Embedded {
+		return opts
+	}
+	flags := cmd.Flags()
+	for flag, path := range map[string]*string{
+		"linguist-language-remote-path":      &opts.LanguagesPath,
+		"linguist-heuristics-remote-path":    &opts.HeuristicsPath,
+		"linguist-vendor-remote-path":        &opts.VendorPath,
+		"linguist-documentation-remote-path": &opts.DocumentationPath,
+		"generated-rules-path":               &opts.GeneratedPath,
+	} {
+		if !flags.Changed(flag) {
+			*path = db.SourceEmbedded
+		}
+	}
+	if !flags.Changed("linguist-grammars-remote-path") {
+		opts.GrammarsPath = ""
+	}
+	return opts
+}
+
+// fetchOptions returns the configured linguist download options, honouring the --no-cache flag
+func fetchOptions() db.FetchOptions {
+	opts := cfg.Linguist.FetchOptions()
+	if noCache {
+		opts.CacheDir = ""
+	}
+	return opts
+}
+
 func migrate(cmd *cobra.Command, args []string) {
 	app := GetApp(cmd).(internal.AppCtx)
+	// concurrent migrate runs, such as parallel CI jobs, queue up behind the lock instead of racing each other
+	if err := db.WithAdvisoryLock(cmd.Context(), app.Conn, db.LanguageSyncLockKey, func() error {
+		return runMigrate(cmd.Context(), app, linguistOptions(cmd))
+	}); err != nil {
+		log.Error().Err(err).Msg("failed to migrate")
+	}
+}
+
+// runMigrate migrates the schema and ingests every Linguist source, the caller holds the sync lock
+func runMigrate(ctx context.Context, app internal.AppCtx, opts db.LinguistOptions) error {
 	if err := db.Migrate(app.StdDB); err != nil {
-		log.Error().Err(err).Msg("failed to migrate database schema")
-		return
+		return fmt.Errorf("failed to migrate database schema: %w", err)
 	}
 	log.Info().Msg("migrated database schema")
-	langCnt, err := app.DB.GetLanguageCount(cmd.Context())
+	summary, err := db.IngestLinguist(ctx, app.Conn, opts)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to get languages")
-		return
-	}
-	if langCnt == 0 {
-		var languages db.LanguagesNonPgtype
-		languages.Load(migrateCfg.LinguistLanguageRemotePath)
-		var languageSource = new(db.LanguageCopyFrom)
-		languageSource.Languages = languages.ToPgType()
-		if _, err := app.Conn.CopyFrom(
-			cmd.Context(),
-			[]string{"languages"},
-			db.LanguageColumns(),
-			languageSource,
-		); err != nil {
-			log.Error().Err(err).Msg("failed to insert language batch to languages table")
-			return
-		}
+		return err
+	}
+	printLanguageSyncSummary(summary.Languages)
+	heuristics := summary.Heuristics
+	fmt.Printf("heuristics: %d disambiguations, %d rules, %d named patterns\n",
+		heuristics.Disambiguations, heuristics.Rules, heuristics.NamedPatterns)
+	pathRules := summary.PathRules
+	fmt.Printf("path rules: %d vendor, %d documentation, %d generated paths, %d generated extensions, %d generated content rules\n",
+		pathRules.Vendor, pathRules.Documentation, pathRules.Generated, pathRules.GeneratedExtensions, pathRules.GeneratedContent)
+	grammars := summary.Grammars
+	if grammars == nil {
+		fmt.Println("grammars: skipped, no grammars.yml source")
+		return nil
+	}
+	fmt.Printf("grammars: %d scopes, %d languages with a missing scope\n", grammars.Scopes, len(grammars.MissingScopes))
+	for _, lang := range grammars.MissingScopes {
+		color.Yellow("  ! %s: %s", lang.Name, lang.TmScope.String)
+	}
+	return nil
+}
+
+// printLanguageSyncSummary writes the added, changed and removed languages to stdout
+func printLanguageSyncSummary(summary *db.LanguageSyncSummary) {
+	fmt.Printf("languages (ingest run %d): %d added, %d changed, %d removed\n",
+		summary.RunID, len(summary.Added), len(summary.Changed), len(summary.Removed))
+	for _, name := range summary.Added {
+		color.Green("  + %s", name)
+	}
+	for _, name := range summary.Changed {
+		color.Yellow("  ~ %s", name)
+	}
+	for _, name := range summary.Removed {
+		color.Red("  - %s", name)
 	}
 }
@@ -31,6 +31,9 @@ var versionCmd = &cobra.Command{
 }
 var timeoutMs int
 
+// exitCode is set by commands whose outcome is meant to be read by scripts, such as languages diff
+var exitCode int
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -38,6 +41,9 @@ func Execute() {
 	if err != nil {
 		os.Exit(1)
 	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
 
 func init() {
@@ -51,9 +57,14 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.seer.yaml)")
 	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "verbose output (-v: info, -vv: debug, -vvv: trace)")
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "always download linguist data in full, bypassing the on-disk cache")
 	rootCmd.PersistentFlags().IntVarP(&timeoutMs, "timeout", "T", int((time.Minute * 1).Milliseconds()), "default timeout for commands in milliseconds, set to 1 minutes by default")
 	rootCmd.AddCommand(server.GetRunCmd())
 	rootCmd.AddCommand(getMigrateCmd())
+	rootCmd.AddCommand(getLanguagesCmd())
+	rootCmd.AddCommand(getDetectCmd())
+	rootCmd.AddCommand(getClassifierCmd())
+	rootCmd.AddCommand(getStatsCmd())
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(migrateCmd)
 }
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/caner-cetin/seer/internal"
+	"github.com/caner-cetin/seer/pkg/analyze"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+type statsConfig struct {
+	Format      string
+	Classifier  string
+	Concurrency int
+	Progress    bool
+	analyze.StatsOptions
+}
+
+// statsProgressInterval is how often the progress line on stderr is redrawn
+const statsProgressInterval = 200 * time.Millisecond
+
+var statsFormats = []string{"table", "json", "yaml"}
+
+var (
+	statsCmd = &cobra.Command{
+		Use:   "stats [--format table|json|yaml] <dir>",
+		Short: "count the bytes and files of each language in a directory tree",
+		Long: "count the bytes and files of each language in a directory tree, rolled up by language group.\n" +
+			".git and paths ignored by .gitignore are skipped, and like Linguist only files in programming and markup languages " +
+			"count unless linguist-detectable says otherwise. Vendored, generated and documentation files are left out unless asked for.\n" +
+			"Files are read in parallel and only as far as detection needs, --timeout bounds the whole run.",
+		Args: cobra.ExactArgs(1),
+		Run:  WrapCommandWithResources(languageStats, ResourceConfig{Resources: []ResourceType{ResourceDatabase}}),
+	}
+	statsCfg statsConfig
+)
+
+func getStatsCmd() *cobra.Command {
+	statsCmd.Flags().StringVar(&statsCfg.Format, "format", "table", "output format, one of "+strings.Join(statsFormats, ", "))
+	statsCmd.Flags().StringVar(&statsCfg.Classifier, "classifier", classifierPath(), "model written by seer classifier train, detection runs without the classifier when the default model does not exist")
+	statsCmd.Flags().IntVar(&statsCfg.Concurrency, "concurrency", runtime.NumCPU(), "number of files analyzed at once")
+	statsCmd.Flags().BoolVar(&statsCfg.Progress, "progress", isTerminal(os.Stderr), "show progress on stderr, on by default when stderr is a terminal")
+	statsCmd.Flags().BoolVar(&statsCfg.Vendored, "vendored", false, "count vendored files")
+	statsCmd.Flags().BoolVar(&statsCfg.Generated, "generated", false, "count generated files")
+	statsCmd.Flags().BoolVar(&statsCfg.Documentation, "documentation", false, "count documentation files")
+	return statsCmd
+}
+
+func languageStats(cmd *cobra.Command, args []string) {
+	app := GetApp(cmd).(internal.AppCtx)
+	if !slices.Contains(statsFormats, statsCfg.Format) {
+		log.Error().Str("format", statsCfg.Format).Msg("unknown output format")
+		return
+	}
+	if statsCfg.Concurrency < 1 {
+		log.Error().Int("concurrency", statsCfg.Concurrency).Msg("concurrency must be at least 1")
+		return
+	}
+	info, err := os.Stat(args[0])
+	if err != nil {
+		log.Error().Err(err).Msg("failed to read directory")
+		return
+	}
+	if !info.IsDir() {
+		log.Error().Str("path", args[0]).Msg("not a directory, use seer detect for single files")
+		return
+	}
+	// attributes and ignore rules are read from the top of the work tree, as git and seer detect do,
+	// while only the given directory is walked
+	root, err := analyze.Root(args[0])
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find work tree")
+		return
+	}
+	abs, err := filepath.Abs(args[0])
+	if err != nil {
+		log.Error().Err(err).Msg("failed to resolve directory")
+		return
+	}
+	dir, err := filepath.Rel(root, abs)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to resolve directory")
+		return
+	}
+	detector, err := newDetector(cmd, app, statsCfg.Classifier)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to build language detector")
+		return
+	}
+	data, err := analyze.Load(cmd.Context(), app.DB)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load path rules")
+		return
+	}
+	analyzer := analyze.New(root, detector, analyze.NewRules(data))
+	tally := analyze.NewTally(detector.Languages(), statsCfg.StatsOptions)
+	results, errc := analyzer.Run(cmd.Context(), filepath.ToSlash(dir), statsCfg.Concurrency)
+	var files, bytes int64
+	lastProgress := time.Now()
+	for result := range results {
+		if result.Err != nil {
+			log.Warn().Err(result.Err).Str("path", result.Path).Msg("skipping unreadable file")
+			continue
+		}
+		tally.Add(result.File, result.Size)
+		files++
+		bytes += result.Size
+		if statsCfg.Progress && time.Since(lastProgress) >= statsProgressInterval {
+			fmt.Fprintf(os.Stderr, "\ranalyzed %d files, %.1f MiB", files, float64(bytes)/(1<<20))
+			lastProgress = time.Now()
+		}
+	}
+	if statsCfg.Progress {
+		// clear the progress line before anything else is written
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+	if err := <-errc; err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Error().Err(err).Int("timeout_ms", timeoutMs).Msg("analysis did not finish in time, raise --timeout")
+			return
+		}
+		log.Error().Err(err).Msg("failed to analyze directory")
+		return
+	}
+	stats := tally.Stats()
+
+	switch statsCfg.Format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stats); err != nil {
+			log.Error().Err(err).Msg("failed to encode language stats")
+		}
+	case "yaml":
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(stats); err != nil {
+			log.Error().Err(err).Msg("failed to encode language stats")
+		}
+	default:
+		if err := printLanguageStats(stats); err != nil {
+			log.Error().Err(err).Msg("failed to write language stats")
+		}
+	}
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func printLanguageStats(stats *analyze.Stats) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LANGUAGE\tFILES\tBYTES\tPERCENT")
+	for _, group := range stats.Languages {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f%%\n", group.Name, group.Files, group.Bytes, group.Percent)
+		for _, lang := range group.Languages {
+			fmt.Fprintf(w, "  %s\t%d\t%d\t%.2f%%\n", lang.Name, lang.Files, lang.Bytes, lang.Percent)
+		}
+	}
+	// an empty tally has no share to account for, so its total is 0% rather than 100%
+	total := 0.0
+	if stats.Bytes > 0 {
+		total = 100
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\t%.2f%%\n", stats.Files, stats.Bytes, total)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(stats.Excluded) == 0 {
+		return nil
+	}
+	reasons := make([]string, 0, len(stats.Excluded))
+	for reason := range stats.Excluded {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	excluded := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		excluded = append(excluded, fmt.Sprintf("%d %s", stats.Excluded[reason], reason))
+	}
+	_, err := fmt.Printf("\nexcluded: %s\n", strings.Join(excluded, ", "))
+	return err
+}
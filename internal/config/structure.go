@@ -2,15 +2,18 @@ package config
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/caner-cetin/seer/pkg/db"
 	"github.com/rs/zerolog/log"
 )
 
 // RootConfig holds the application configuration settings
 type RootConfig struct {
-	DB                    dbConfig `mapstructure:"db" yaml:"db"`
-	DisplayAsciiArtOnHelp bool     `mapstructure:"display_ascii_art_on_help" yaml:"display_ascii_art_on_help"`
-	Path                  string   `mapstructure:"-" yaml:"-"`
+	DB                    dbConfig       `mapstructure:"db" yaml:"db"`
+	Linguist              linguistConfig `mapstructure:"linguist" yaml:"linguist"`
+	DisplayAsciiArtOnHelp bool           `mapstructure:"display_ascii_art_on_help" yaml:"display_ascii_art_on_help"`
+	Path                  string         `mapstructure:"-" yaml:"-"`
 }
 
 type dbConfig struct {
@@ -26,6 +29,23 @@ type dbAuthConfig struct {
 	Database string `mapstructure:"database" yaml:"database"`
 }
 
+type linguistConfig struct {
+	// LanguagesPath is the languages.yml synced by the server, defaults to db.DefaultLanguagesPath
+	LanguagesPath string `mapstructure:"languages_path" yaml:"languages_path"`
+	// Overlays are organization specific languages.yml files merged over LanguagesPath, in order
+	Overlays []string `mapstructure:"overlays" yaml:"overlays"`
+	// SyncInterval is how often the server re-syncs languages, as a Go duration string such as 6h. Empty disables it
+	SyncInterval string `mapstructure:"sync_interval" yaml:"sync_interval"`
+	// FetchTimeout bounds a single download attempt, as a Go duration string such as 30s
+	FetchTimeout string `mapstructure:"fetch_timeout" yaml:"fetch_timeout"`
+	// FetchRetries is the number of extra attempts made after a failed download
+	FetchRetries *int `mapstructure:"fetch_retries" yaml:"fetch_retries"`
+	// CacheDir overrides where downloads are cached, "none" disables the cache
+	CacheDir string `mapstructure:"cache_dir" yaml:"cache_dir"`
+	// ClassifierPath is the model written by seer classifier train and read by detection, the CLI defaults it to detect.DefaultClassifierPath
+	ClassifierPath string `mapstructure:"classifier_path" yaml:"classifier_path"`
+}
+
 // Config is Config. how helpful.
 var Config RootConfig
 
@@ -54,3 +74,48 @@ func (c *RootConfig) SetDBURL() {
 		c.DB.Auth.Database,
 	)
 }
+
+// SyncEvery parses SyncInterval, returning zero when periodic syncs are disabled or misconfigured
+func (c linguistConfig) SyncEvery() time.Duration {
+	if c.SyncInterval == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(c.SyncInterval)
+	if err != nil || interval < 0 {
+		log.Warn().Err(err).Str("sync_interval", c.SyncInterval).Msg("invalid linguist sync interval, periodic syncs are disabled")
+		return 0
+	}
+	return interval
+}
+
+// Languages returns LanguagesPath, or db.DefaultLanguagesPath when it is unset
+func (c linguistConfig) Languages() string {
+	if c.LanguagesPath == "" {
+		return db.DefaultLanguagesPath
+	}
+	return c.LanguagesPath
+}
+
+// FetchOptions builds the linguist download options, falling back to db.DefaultFetchOptions for anything unset
+func (c linguistConfig) FetchOptions() db.FetchOptions {
+	opts := db.DefaultFetchOptions()
+	if c.FetchTimeout != "" {
+		timeout, err := time.ParseDuration(c.FetchTimeout)
+		if err != nil {
+			log.Warn().Err(err).Str("fetch_timeout", c.FetchTimeout).Msg("invalid linguist fetch timeout, using the default")
+		} else {
+			opts.Timeout = timeout
+		}
+	}
+	if c.FetchRetries != nil {
+		opts.Retries = *c.FetchRetries
+	}
+	switch c.CacheDir {
+	case "":
+	case "none":
+		opts.CacheDir = ""
+	default:
+		opts.CacheDir = c.CacheDir
+	}
+	return opts
+}
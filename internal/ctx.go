@@ -10,6 +10,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	pgstdlib "github.com/jackc/pgx/v5/stdlib"
 )
 
@@ -22,6 +23,7 @@ type AppCtx struct {
 	DB      *db.Queries
 	StdDB   *sql.DB
 	Conn    *pgx.Conn
+	Pool    *pgxpool.Pool
 	Context context.Context
 }
 
@@ -42,7 +44,32 @@ func (ctx *AppCtx) InitializeDB() error {
 	return nil
 }
 
+// InitializePool connects through a connection pool instead of a single connection, for long running
+// processes such as the server where handlers and background jobs query concurrently
+func (ctx *AppCtx) InitializePool() error {
+	ctx.Context = context.TODO()
+	conf, err := pgxpool.ParseConfig(config.Config.DB.URL)
+	if err != nil {
+		return fmt.Errorf("failed to parse database config: %w", err)
+	}
+	pool, err := pgxpool.NewWithConfig(ctx.Context, conf)
+	if err != nil {
+		return fmt.Errorf("failed to create database pool: %w", err)
+	}
+	ctx.DB = db.New(pool)
+	ctx.Pool = pool
+	ctx.StdDB = pgstdlib.OpenDB(*conf.ConnConfig)
+	return nil
+}
+
 func (ctx *AppCtx) Cleanup() {
+	if ctx.Pool != nil {
+		ctx.Pool.Close()
+		if err := ctx.StdDB.Close(); err != nil {
+			log.Error().Err(err).Msg("failed to close sql.DB connection")
+		}
+		return
+	}
 	if ctx.Conn != nil && !ctx.Conn.IsClosed() {
 		err := ctx.Conn.Close(ctx.Context)
 		if err != nil {
@@ -0,0 +1,178 @@
+// Package analyze classifies the files of a directory tree: the language each one is written in and whether it is
+// vendored, generated or documentation, applying .gitattributes linguist overrides on top of detection
+package analyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/caner-cetin/seer/pkg/detect"
+	"github.com/rs/zerolog/log"
+)
+
+// Linguist attributes overriding what seer works out on its own
+const (
+	AttributeLanguage      = "linguist-language"
+	AttributeVendored      = "linguist-vendored"
+	AttributeGenerated     = "linguist-generated"
+	AttributeDocumentation = "linguist-documentation"
+	AttributeDetectable    = "linguist-detectable"
+)
+
+// Mark is a yes or no property of a file and what decided it
+type Mark struct {
+	Set bool `json:"set"`
+	// Source is the attribute line, path rule or content rule that decided, empty when nothing did
+	Source string `json:"source,omitempty"`
+}
+
+// File is what the analyzer found out about one file
+type File struct {
+	// Path is slash separated and relative to the analyzed root
+	Path string `json:"path"`
+	detect.Result
+	Vendored      Mark `json:"vendored"`
+	Generated     Mark `json:"generated"`
+	Documentation Mark `json:"documentation"`
+	// Detectable files count towards language statistics, by default those in a programming or markup language
+	Detectable Mark `json:"detectable"`
+}
+
+// Analyzer classifies the files under one root, it is safe for concurrent use
+type Analyzer struct {
+	root       string
+	detector   *detect.Detector
+	rules      *Rules
+	attributes *Attributes
+}
+
+// New returns an analyzer for the files under root
+func New(root string, detector *detect.Detector, rules *Rules) *Analyzer {
+	return &Analyzer{root: root, detector: detector, rules: rules, attributes: NewAttributes(root)}
+}
+
+// Read classifies a file under the root from its slash separated path, reading only the head and tail detection
+// needs, and returns its size. However large the file, Read holds at most detect.ConsiderBytes and
+// detect.TailBytes of it.
+func (a *Analyzer) Read(name string) (File, int64, error) {
+	f, err := os.Open(filepath.Join(a.root, filepath.FromSlash(name)))
+	if err != nil {
+		return File{}, 0, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return File{}, 0, fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	size := info.Size()
+	if size <= detect.ConsiderBytes+detect.TailBytes {
+		content := make([]byte, size)
+		n, err := io.ReadFull(f, content)
+		// a file shrinking under us is analyzed as far as it goes
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return File{}, 0, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return a.sample(name, content[:n], nil), int64(n), nil
+	}
+	head := make([]byte, detect.ConsiderBytes)
+	if _, err := io.ReadFull(f, head); err != nil {
+		return File{}, 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	tail := make([]byte, detect.TailBytes)
+	if _, err := f.ReadAt(tail, size-detect.TailBytes); err != nil {
+		return File{}, 0, fmt.Errorf("failed to read the end of %s: %w", name, err)
+	}
+	return a.sample(name, head, tail), size, nil
+}
+
+// File classifies one file from its slash separated path relative to the root and its content.
+// Linguist attributes win over detection and the path and content rules.
+func (a *Analyzer) File(name string, content []byte) File {
+	return a.sample(name, content, nil)
+}
+
+// sample is File for a file that may have been read in part, see detect.DetectSample
+func (a *Analyzer) sample(name string, content, tail []byte) File {
+	attrs := a.attributes.Lookup(name)
+	f := File{Path: name}
+	if attr, ok := attrs[AttributeLanguage]; ok {
+		if lang, ok := a.detector.LanguageByAlias(attr.Value); ok {
+			f.Content = detect.Classify(content)
+			f.Candidates = []detect.Candidate{{
+				LanguageID: lang.LanguageID,
+				Name:       lang.Name,
+				Strategy:   detect.StrategyAttribute,
+				Reason:     fmt.Sprintf("%s=%s in %s", AttributeLanguage, attr.Value, attr.Source),
+			}}
+		} else {
+			log.Warn().Str("language", attr.Value).Str("source", attr.Source).Msg("unknown language in attributes, detecting it instead")
+		}
+	}
+	if f.Candidates == nil {
+		f.Result = a.detector.DetectSample(name, content, tail)
+	}
+
+	f.Vendored = a.mark(attrs, AttributeVendored, func() (string, bool) {
+		return a.rules.matchPath(db.PathRuleKindVendor, name)
+	})
+	f.Documentation = a.mark(attrs, AttributeDocumentation, func() (string, bool) {
+		return a.rules.matchPath(db.PathRuleKindDocumentation, name)
+	})
+	f.Generated = a.mark(attrs, AttributeGenerated, func() (string, bool) {
+		return a.rules.generated(name, content, tail, f.Content.Binary)
+	})
+	f.Detectable = a.mark(attrs, AttributeDetectable, func() (string, bool) {
+		best, ok := f.Language()
+		if !ok {
+			return "", false
+		}
+		lang, ok := a.detector.Language(best.LanguageID)
+		if !ok || !lang.Type.Valid {
+			return "", false
+		}
+		switch lang.Type.LanguageType {
+		case db.LanguageTypeProgramming, db.LanguageTypeMarkup:
+			return fmt.Sprintf("%s language", lang.Type.LanguageType), true
+		}
+		return "", false
+	})
+	return f
+}
+
+// mark reads a boolean attribute, falling back to rule when it is unspecified
+func (a *Analyzer) mark(attrs map[string]Attribute, attribute string, rule func() (string, bool)) Mark {
+	if attr, ok := attrs[attribute]; ok {
+		return Mark{Set: attr.Bool(), Source: fmt.Sprintf("%s=%s in %s", attribute, attr.Value, attr.Source)}
+	}
+	source, ok := rule()
+	return Mark{Set: ok, Source: source}
+}
+
+// Root returns the top of the git work tree holding path, or the directory of path when it is in none
+func Root(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	dir := abs
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		dir = filepath.Dir(abs)
+	}
+	for candidate := dir; ; {
+		if _, err := os.Stat(filepath.Join(candidate, ".git")); err == nil {
+			return candidate, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to look for .git in %s: %w", candidate, err)
+		}
+		parent := filepath.Dir(candidate)
+		if parent == candidate {
+			return dir, nil
+		}
+		candidate = parent
+	}
+}
@@ -0,0 +1,179 @@
+package analyze
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Attribute is the state of a gitattribute for a path and the line that decided it
+type Attribute struct {
+	// Value is "true" when the attribute is set, "false" when it is unset and otherwise the assigned value
+	Value string
+	// Source is the file and line that last set the attribute, relative to the root, such as docs/.gitattributes:3
+	Source string
+}
+
+// Bool reads the attribute the way Linguist reads its boolean attributes, anything but unset or false is true
+func (a Attribute) Bool() bool {
+	return a.Value != "false"
+}
+
+// attributeLine is one pattern line of an attributes file
+type attributeLine struct {
+	globPattern
+	source  string
+	assigns []attributeAssign
+}
+
+type attributeAssign struct {
+	name  string
+	value string
+	// reset is set by !attr, which makes the attribute unspecified again
+	reset bool
+}
+
+// Attributes reads the attribute files of a directory tree, each one once, and is safe for concurrent use.
+// Like git, it reads .gitattributes in every directory from the root down to a file, deeper files and later
+// lines taking precedence, and .git/info/attributes above all of them.
+type Attributes struct {
+	root string
+	info []attributeLine
+	mu   sync.Mutex
+	dirs map[string][]attributeLine
+}
+
+// NewAttributes returns the attributes of the tree under root
+func NewAttributes(root string) *Attributes {
+	a := &Attributes{root: root, dirs: make(map[string][]attributeLine)}
+	a.info = a.read(filepath.Join(root, ".git", "info", "attributes"), "", ".git/info/attributes")
+	return a
+}
+
+// Lookup returns the attributes specified for a slash separated path relative to the root, keyed by name
+func (a *Attributes) Lookup(name string) map[string]Attribute {
+	attrs := make(map[string]Attribute)
+	dirs := []string{""}
+	for i, c := range name {
+		if c == '/' {
+			dirs = append(dirs, name[:i])
+		}
+	}
+	for _, dir := range dirs {
+		apply(attrs, a.dir(dir), name)
+	}
+	apply(attrs, a.info, name)
+	return attrs
+}
+
+func apply(attrs map[string]Attribute, lines []attributeLine, name string) {
+	for _, line := range lines {
+		if !line.matches(name) {
+			continue
+		}
+		for _, assign := range line.assigns {
+			if assign.reset {
+				delete(attrs, assign.name)
+				continue
+			}
+			attrs[assign.name] = Attribute{Value: assign.value, Source: line.source}
+		}
+	}
+}
+
+// dir returns the lines of the .gitattributes file in dir, reading it on first use
+func (a *Attributes) dir(dir string) []attributeLine {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if lines, ok := a.dirs[dir]; ok {
+		return lines
+	}
+	source := path.Join(dir, ".gitattributes")
+	lines := a.read(filepath.Join(a.root, filepath.FromSlash(source)), dir, source)
+	a.dirs[dir] = lines
+	return lines
+}
+
+// read parses an attributes file
+func (a *Attributes) read(file, dir, source string) []attributeLine {
+	var lines []attributeLine
+	for i, text := range readPatternFile(file) {
+		lineSource := fmt.Sprintf("%s:%d", source, i+1)
+		line, err := parseAttributeLine(text)
+		if err != nil {
+			log.Warn().Err(err).Str("source", lineSource).Msg("ignoring attributes line")
+			continue
+		}
+		if line == nil {
+			continue
+		}
+		line.dir = dir
+		line.source = lineSource
+		lines = append(lines, *line)
+	}
+	return lines
+}
+
+// parseAttributeLine parses "pattern attr1 attr2 ...", returning nil for blank lines, comments and lines that
+// can never match a file
+func parseAttributeLine(text string) (*attributeLine, error) {
+	text = strings.TrimLeft(text, " \t")
+	if text == "" || strings.HasPrefix(text, "#") {
+		return nil, nil
+	}
+	var pattern, rest string
+	if strings.HasPrefix(text, `"`) {
+		end := 1
+		for end < len(text) && text[end] != '"' {
+			if text[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(text) {
+			return nil, fmt.Errorf("unterminated quoted pattern")
+		}
+		unquoted, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted pattern: %w", err)
+		}
+		pattern, rest = unquoted, text[end+1:]
+	} else {
+		pattern, rest, _ = strings.Cut(strings.ReplaceAll(text, "\t", " "), " ")
+	}
+	switch {
+	case strings.HasPrefix(pattern, "[attr]"):
+		// macro definitions only matter to git itself, none of them touch linguist attributes
+		return nil, nil
+	case strings.HasPrefix(pattern, "!"):
+		return nil, fmt.Errorf("negative patterns are ignored in gitattributes, use \\! for a literal !")
+	case strings.HasSuffix(pattern, "/"):
+		// a pattern ending in a slash only matches directories, and attributes never apply to directories
+		return nil, nil
+	}
+	glob, err := newGlobPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	line := &attributeLine{globPattern: glob}
+	for _, field := range strings.Fields(rest) {
+		switch {
+		case strings.HasPrefix(field, "-"):
+			line.assigns = append(line.assigns, attributeAssign{name: field[1:], value: "false"})
+		case strings.HasPrefix(field, "!"):
+			line.assigns = append(line.assigns, attributeAssign{name: field[1:], reset: true})
+		default:
+			name, value, ok := strings.Cut(field, "=")
+			if !ok {
+				value = "true"
+			}
+			line.assigns = append(line.assigns, attributeAssign{name: name, value: value})
+		}
+	}
+	return line, nil
+}
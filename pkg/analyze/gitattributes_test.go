@@ -0,0 +1,107 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates files under root from slash separated paths
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAttributesLookup(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitattributes": "# linguist overrides\n" +
+			"*.js linguist-vendored\n" +
+			"*.min.js linguist-generated=true\n" +
+			"docs/** linguist-documentation\n" +
+			"*.inc linguist-language=PHP\n" +
+			"\"with space.txt\" linguist-detectable\n",
+		"lib/.gitattributes":      "*.js -linguist-vendored\n*.inc linguist-language=Pascal\n",
+		"lib/keep/.gitattributes": "*.js !linguist-vendored\n",
+		".git/info/attributes":    "secret.js linguist-vendored=false\n",
+	})
+	a := NewAttributes(root)
+
+	tests := []struct {
+		name      string
+		attribute string
+		want      Attribute
+		unset     bool
+	}{
+		{"app.js", "linguist-vendored", Attribute{Value: "true", Source: ".gitattributes:2"}, false},
+		{"app.min.js", "linguist-generated", Attribute{Value: "true", Source: ".gitattributes:3"}, false},
+		{"docs/a/b.md", "linguist-documentation", Attribute{Value: "true", Source: ".gitattributes:4"}, false},
+		{"with space.txt", "linguist-detectable", Attribute{Value: "true", Source: ".gitattributes:6"}, false},
+		// a deeper .gitattributes takes precedence over the root one
+		{"lib/app.js", "linguist-vendored", Attribute{Value: "false", Source: "lib/.gitattributes:1"}, false},
+		{"lib/a.inc", "linguist-language", Attribute{Value: "Pascal", Source: "lib/.gitattributes:2"}, false},
+		{"a.inc", "linguist-language", Attribute{Value: "PHP", Source: ".gitattributes:5"}, false},
+		// !attr makes the attribute unspecified again
+		{"lib/keep/app.js", "linguist-vendored", Attribute{}, true},
+		// .git/info/attributes comes last
+		{"secret.js", "linguist-vendored", Attribute{Value: "false", Source: ".git/info/attributes:1"}, false},
+		{"main.go", "linguist-vendored", Attribute{}, true},
+	}
+	for _, tt := range tests {
+		got, ok := a.Lookup(tt.name)[tt.attribute]
+		if tt.unset {
+			if ok {
+				t.Errorf("Lookup(%q)[%q] = %+v, want unspecified", tt.name, tt.attribute, got)
+			}
+			continue
+		}
+		if !ok || got != tt.want {
+			t.Errorf("Lookup(%q)[%q] = %+v, want %+v", tt.name, tt.attribute, got, tt.want)
+		}
+	}
+}
+
+func TestParseAttributeLine(t *testing.T) {
+	tests := []struct {
+		text    string
+		skipped bool
+		wantErr bool
+	}{
+		{"", true, false},
+		{"  # comment", true, false},
+		{"[attr]binary -diff -merge -text", true, false},
+		{"docs/ linguist-documentation", true, false},
+		{"!*.go linguist-vendored", false, true},
+		{`"unterminated linguist-vendored`, false, true},
+		{"*.go\tlinguist-vendored", false, false},
+	}
+	for _, tt := range tests {
+		line, err := parseAttributeLine(tt.text)
+		switch {
+		case tt.wantErr:
+			if err == nil {
+				t.Errorf("parseAttributeLine(%q) succeeded, want an error", tt.text)
+			}
+		case err != nil:
+			t.Errorf("parseAttributeLine(%q) failed: %v", tt.text, err)
+		case tt.skipped != (line == nil):
+			t.Errorf("parseAttributeLine(%q) = %+v, skipped should be %v", tt.text, line, tt.skipped)
+		}
+	}
+}
+
+func TestAttributeBool(t *testing.T) {
+	for value, want := range map[string]bool{"true": true, "false": false, "1": true, "0": true} {
+		if got := (Attribute{Value: value}).Bool(); got != want {
+			t.Errorf("Attribute{Value: %q}.Bool() = %v, want %v", value, got, want)
+		}
+	}
+}
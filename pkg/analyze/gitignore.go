@@ -0,0 +1,129 @@
+package analyze
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ignoreLine is one pattern line of a .gitignore file
+type ignoreLine struct {
+	globPattern
+	// negate is set for !pattern, which includes again what an earlier pattern excluded
+	negate bool
+	// dirOnly is set for patterns ending in a slash, they only match directories
+	dirOnly bool
+}
+
+// Ignore reads the .gitignore files of a directory tree, each one once, and is safe for concurrent use.
+// Like git, a .gitignore in a deeper directory takes precedence over those above it, the last matching line of
+// a file wins and .git/info/exclude comes last. The global core.excludesFile is not read.
+type Ignore struct {
+	root    string
+	exclude []ignoreLine
+	mu      sync.Mutex
+	dirs    map[string][]ignoreLine
+}
+
+// NewIgnore returns the ignore rules of the tree under root
+func NewIgnore(root string) *Ignore {
+	ig := &Ignore{root: root, dirs: make(map[string][]ignoreLine)}
+	ig.exclude = readIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), "")
+	return ig
+}
+
+// Ignored reports whether a slash separated path relative to the root is ignored. Callers walk the tree from the
+// root and skip ignored directories, since git never includes a file again once its directory is ignored.
+func (ig *Ignore) Ignored(name string, dir bool) bool {
+	dirs := []string{""}
+	for i, c := range name {
+		if c == '/' {
+			dirs = append(dirs, name[:i])
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if matched, ignored := matchIgnore(ig.dir(dirs[i]), name, dir); matched {
+			return ignored
+		}
+	}
+	_, ignored := matchIgnore(ig.exclude, name, dir)
+	return ignored
+}
+
+// matchIgnore finds the last line matching name, reporting whether one did and whether it ignores name
+func matchIgnore(lines []ignoreLine, name string, dir bool) (bool, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if line.dirOnly && !dir {
+			continue
+		}
+		if line.matches(name) {
+			return true, !line.negate
+		}
+	}
+	return false, false
+}
+
+// dir returns the lines of the .gitignore file in dir, reading it on first use
+func (ig *Ignore) dir(dir string) []ignoreLine {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	if lines, ok := ig.dirs[dir]; ok {
+		return lines
+	}
+	lines := readIgnoreFile(filepath.Join(ig.root, filepath.FromSlash(path.Join(dir, ".gitignore"))), dir)
+	ig.dirs[dir] = lines
+	return lines
+}
+
+func readIgnoreFile(file, dir string) []ignoreLine {
+	var lines []ignoreLine
+	for i, text := range readPatternFile(file) {
+		line, ok := parseIgnoreLine(text)
+		if !ok {
+			continue
+		}
+		glob, err := newGlobPattern(line.pattern)
+		if err != nil {
+			log.Warn().Err(err).Str("path", file).Int("line", i+1).Msg("ignoring gitignore line")
+			continue
+		}
+		glob.dir = dir
+		lines = append(lines, ignoreLine{globPattern: glob, negate: line.negate, dirOnly: line.dirOnly})
+	}
+	return lines
+}
+
+type parsedIgnoreLine struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// parseIgnoreLine reads one .gitignore line, reporting false for blank lines and comments
+func parseIgnoreLine(text string) (parsedIgnoreLine, bool) {
+	// trailing spaces are dropped unless the last one is escaped
+	for strings.HasSuffix(text, " ") && !strings.HasSuffix(text, `\ `) {
+		text = text[:len(text)-1]
+	}
+	if text == "" || strings.HasPrefix(text, "#") {
+		return parsedIgnoreLine{}, false
+	}
+	var line parsedIgnoreLine
+	if strings.HasPrefix(text, "!") {
+		line.negate = true
+		text = text[1:]
+	}
+	if strings.HasSuffix(text, "/") {
+		line.dirOnly = true
+		text = strings.TrimRight(text, "/")
+	}
+	if text == "" {
+		return parsedIgnoreLine{}, false
+	}
+	line.pattern = text
+	return line, true
+}
@@ -0,0 +1,98 @@
+package analyze
+
+import (
+	"io/fs"
+	"slices"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore": "# build output\n" +
+			"*.log\n" +
+			"!keep.log\n" +
+			"build/\n" +
+			"/local.txt\n" +
+			"trailing.txt   \n",
+		"src/.gitignore":     "!debug.log\nlocal.txt\n",
+		"src/sub/.gitignore": "*.txt\n!notes.txt\n",
+		".git/info/exclude":  "*.tmp\n",
+	})
+	ig := NewIgnore(root)
+
+	tests := []struct {
+		name string
+		dir  bool
+		want bool
+	}{
+		{"app.log", false, true},
+		{"keep.log", false, false},
+		{"src/deep/app.log", false, true},
+		// a deeper .gitignore takes precedence over the root one
+		{"src/debug.log", false, false},
+		{"src/other.log", false, true},
+		{"build", true, true},
+		{"build", false, false},
+		{"src/sub/build", true, true},
+		{"local.txt", false, true},
+		{"src/local.txt", false, true},
+		{"src/sub/local.txt", false, true},
+		{"trailing.txt", false, true},
+		{"src/sub/readme.txt", false, true},
+		{"src/sub/notes.txt", false, false},
+		{"main.go", false, false},
+		// .git/info/exclude comes after every .gitignore
+		{"a.tmp", false, true},
+		{"a.tmp2", false, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Ignored(tt.name, tt.dir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.name, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":        "*.log\nvendor/\n",
+		"main.go":           "",
+		"debug.log":         "",
+		"vendor/lib.go":     "",
+		"src/app.go":        "",
+		"src/app.log":       "",
+		"src/.gitignore":    "gen/\n",
+		"src/gen/out.go":    "",
+		"src/pkg/util.go":   "",
+		".git/HEAD":         "",
+		"sub/.git":          "gitdir: ../.git/modules/sub\n",
+		"sub/module.go":     "",
+		".git/info/exclude": "",
+	})
+
+	walk := func(dir string) []string {
+		var names []string
+		if err := Walk(root, dir, func(name string, _ fs.DirEntry) error {
+			names = append(names, name)
+			return nil
+		}); err != nil {
+			t.Fatalf("Walk(%q) failed: %v", dir, err)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	want := []string{".gitignore", "main.go", "src/.gitignore", "src/app.go", "src/pkg/util.go", "sub/module.go"}
+	if got := walk(""); !slices.Equal(got, want) {
+		t.Errorf("Walk(root) = %v, want %v", got, want)
+	}
+	// walking a directory keeps paths relative to the root and the .gitignore files above it
+	want = []string{"src/.gitignore", "src/app.go", "src/pkg/util.go"}
+	if got := walk("src"); !slices.Equal(got, want) {
+		t.Errorf("Walk(src) = %v, want %v", got, want)
+	}
+	if err := Walk(root, "missing", func(string, fs.DirEntry) error { return nil }); err == nil {
+		t.Error("Walk(missing) succeeded, want an error")
+	}
+}
@@ -0,0 +1,150 @@
+package analyze
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// globPattern is a pattern of a .gitattributes or .gitignore file
+type globPattern struct {
+	re *regexp.Regexp
+	// basename is set for patterns without a slash, they match the file name at any depth below their file
+	basename bool
+	// dir is the slash separated directory of the pattern's file relative to the root, empty for the root itself
+	dir string
+}
+
+// newGlobPattern compiles a pattern, its trailing slash already stripped for .gitignore directory patterns
+func newGlobPattern(pattern string) (globPattern, error) {
+	re, err := compileGlob(strings.TrimPrefix(pattern, "/"))
+	if err != nil {
+		return globPattern{}, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	return globPattern{re: re, basename: !strings.Contains(pattern, "/")}, nil
+}
+
+// matches reports whether the pattern applies to a slash separated path relative to the root
+func (p globPattern) matches(name string) bool {
+	if p.dir != "" {
+		if !strings.HasPrefix(name, p.dir+"/") {
+			return false
+		}
+		name = name[len(p.dir)+1:]
+	}
+	if p.basename {
+		name = path.Base(name)
+	}
+	return p.re.MatchString(name)
+}
+
+// readPatternFile returns the lines of a .gitattributes or .gitignore style file, a file that cannot be read
+// only loses its patterns
+func readPatternFile(file string) []string {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Warn().Err(err).Str("path", file).Msg("failed to read pattern file, ignoring it")
+		}
+		return nil
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		log.Warn().Err(err).Str("path", file).Msg("failed to read pattern file, ignoring the rest of it")
+	}
+	return lines
+}
+
+// compileGlob turns a gitignore style glob into an anchored regular expression. * and ? never match a slash,
+// a leading **/ matches in every directory, a trailing /** matches everything inside and /**/ matches zero or
+// more directories. Any other ** is an ordinary *.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	var out strings.Builder
+	out.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**") && (i == 0 || glob[i-1] == '/') && (i+2 == len(glob) || glob[i+2] == '/'):
+			if i+2 == len(glob) {
+				out.WriteString(".*")
+				i++
+			} else {
+				out.WriteString("(?:.*/)?")
+				i += 2
+			}
+		case c == '*':
+			out.WriteString("[^/]*")
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+		case c == '?':
+			out.WriteString("[^/]")
+		case c == '[':
+			class, n, ok := globClass(glob[i:])
+			if !ok {
+				out.WriteString(`\[`)
+				continue
+			}
+			out.WriteString(class)
+			i += n - 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			out.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			out.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	out.WriteString("$")
+	return regexp.Compile(out.String())
+}
+
+// globClass translates the bracket expression at the start of glob, returning how many bytes it spans.
+// It reports false when the bracket is never closed, git then reads the [ literally.
+func globClass(glob string) (string, int, bool) {
+	var out strings.Builder
+	out.WriteString("[")
+	i := 1
+	if i < len(glob) && (glob[i] == '!' || glob[i] == '^') {
+		// a negated class still never matches a slash
+		out.WriteString("^/")
+		i++
+	}
+	if i < len(glob) && glob[i] == ']' {
+		out.WriteString(`\]`)
+		i++
+	}
+	for ; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == ']':
+			out.WriteString("]")
+			return out.String(), i + 1, true
+		case strings.HasPrefix(glob[i:], "[:"):
+			end := strings.Index(glob[i:], ":]")
+			if end < 0 {
+				return "", 0, false
+			}
+			out.WriteString(glob[i : i+end+2])
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			out.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[' || c == '\\':
+			out.WriteString(`\` + string(c))
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
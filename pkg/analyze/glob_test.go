@@ -0,0 +1,52 @@
+package analyze
+
+import "testing"
+
+func TestGlobPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		dir     string
+		name    string
+		want    bool
+	}{
+		{"*.go", "", "main.go", true},
+		{"*.go", "", "cmd/cli/main.go", true},
+		{"*.go", "", "main.go.orig", false},
+		{"*.go", "cmd", "cmd/main.go", true},
+		{"*.go", "cmd", "pkg/main.go", false},
+		{"/main.go", "", "main.go", true},
+		{"/main.go", "", "cmd/main.go", false},
+		{"cmd/*.go", "", "cmd/main.go", true},
+		{"cmd/*.go", "", "cmd/cli/main.go", false},
+		{"cmd/*.go", "", "pkg/cmd/main.go", false},
+		{"**/testdata/*", "", "testdata/a.txt", true},
+		{"**/testdata/*", "", "pkg/x/testdata/a.txt", true},
+		{"vendor/**", "", "vendor/a/b/c.go", true},
+		{"vendor/**", "", "vendor", false},
+		{"a/**/b.go", "", "a/b.go", true},
+		{"a/**/b.go", "", "a/x/y/b.go", true},
+		{"a/**b.go", "", "a/xb.go", true},
+		{"a/**b.go", "", "a/x/b.go", false},
+		{"?.go", "", "a.go", true},
+		{"?.go", "", "ab.go", false},
+		{"[abc].go", "", "b.go", true},
+		{"[abc].go", "", "d.go", false},
+		{"[!abc].go", "", "d.go", true},
+		{"[!abc].go", "", "a.go", false},
+		{"[[:digit:]].txt", "", "7.txt", true},
+		{"[a.go", "", "[a.go", true},
+		{`\*.go`, "", "*.go", true},
+		{`\*.go`, "", "a.go", false},
+		{"a+b(c).go", "", "a+b(c).go", true},
+	}
+	for _, tt := range tests {
+		p, err := newGlobPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("newGlobPattern(%q) failed: %v", tt.pattern, err)
+		}
+		p.dir = tt.dir
+		if got := p.matches(tt.name); got != tt.want {
+			t.Errorf("pattern %q in %q matches(%q) = %v, want %v", tt.pattern, tt.dir, tt.name, got, tt.want)
+		}
+	}
+}
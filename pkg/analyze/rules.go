@@ -0,0 +1,179 @@
+package analyze
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/caner-cetin/seer/pkg/detect"
+	"github.com/rs/zerolog/log"
+)
+
+// Data is what Rules are built from, see Load
+type Data struct {
+	PathRules           []db.PathRule
+	GeneratedExtensions []string
+	GeneratedContent    []db.GeneratedContentRule
+}
+
+// Rules are the compiled vendored, documentation and generated file rules
+type Rules struct {
+	paths               map[db.PathRuleKind][]pathRule
+	generatedExtensions []string
+	generatedContent    []contentRule
+}
+
+type pathRule struct {
+	source string
+	re     *regexp.Regexp
+}
+
+type contentRule struct {
+	name                 string
+	extensions           []string
+	pattern              *regexp.Regexp
+	lines                int32
+	minAverageLineLength int32
+}
+
+// Load reads the path and generated file rules through q
+func Load(ctx context.Context, q db.Querier) (*Data, error) {
+	var data Data
+	var err error
+	if data.PathRules, err = q.GetPathRules(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get path rules: %w", err)
+	}
+	if data.GeneratedExtensions, err = q.GetGeneratedExtensions(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get generated extensions: %w", err)
+	}
+	if data.GeneratedContent, err = q.GetGeneratedContentRules(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get generated content rules: %w", err)
+	}
+	return &data, nil
+}
+
+// NewRules compiles the rules once. Path rules are Ruby regular expressions, one RE2 cannot express is skipped.
+func NewRules(data *Data) *Rules {
+	r := &Rules{
+		paths:               make(map[db.PathRuleKind][]pathRule),
+		generatedExtensions: data.GeneratedExtensions,
+	}
+	for _, row := range data.PathRules {
+		translated, err := detect.TranslateRubyRegexp(row.Pattern)
+		if err != nil {
+			log.Debug().Err(err).Str("kind", string(row.Kind)).Str("pattern", row.Pattern).Msg("skipped path rule")
+			continue
+		}
+		re, err := regexp.Compile(translated)
+		if err != nil {
+			log.Debug().Err(err).Str("kind", string(row.Kind)).Str("pattern", row.Pattern).Msg("skipped path rule")
+			continue
+		}
+		r.paths[row.Kind] = append(r.paths[row.Kind], pathRule{source: row.Pattern, re: re})
+	}
+	for _, row := range data.GeneratedContent {
+		rule := contentRule{name: row.Name, extensions: row.Extensions, lines: row.Lines}
+		if row.Pattern.Valid {
+			re, err := regexp.Compile(row.Pattern.String)
+			if err != nil {
+				log.Debug().Err(err).Str("rule", row.Name).Msg("skipped generated content rule")
+				continue
+			}
+			rule.pattern = re
+		}
+		if row.MinAverageLineLength.Valid {
+			rule.minAverageLineLength = row.MinAverageLineLength.Int32
+		}
+		r.generatedContent = append(r.generatedContent, rule)
+	}
+	return r
+}
+
+// matchPath returns the first rule of kind matching a slash separated path relative to the root
+func (r *Rules) matchPath(kind db.PathRuleKind, name string) (string, bool) {
+	for _, rule := range r.paths[kind] {
+		if rule.re.MatchString(name) {
+			return fmt.Sprintf("%s rule %s", kind, rule.source), true
+		}
+	}
+	return "", false
+}
+
+// generated tells whether a file is generated from its path, its extension or, for text, its content.
+// tail is the end of a file content was cut short of, see detect.DetectSample, or nil.
+func (r *Rules) generated(name string, content, tail []byte, binary bool) (string, bool) {
+	if source, ok := r.matchPath(db.PathRuleKindGenerated, name); ok {
+		return source, true
+	}
+	lower := strings.ToLower(name)
+	for _, ext := range r.generatedExtensions {
+		if strings.HasSuffix(lower, strings.ToLower(ext)) {
+			return "generated extension " + ext, true
+		}
+	}
+	if binary {
+		return "", false
+	}
+	var lines, tailLines [][]byte
+	for _, rule := range r.generatedContent {
+		if !rule.covers(lower) {
+			continue
+		}
+		if lines == nil {
+			lines = bytes.Split(bytes.TrimSuffix(content, []byte("\n")), []byte("\n"))
+			tailLines = lines
+			if tail != nil {
+				// tail may start in the middle of a line
+				tailLines = bytes.Split(bytes.TrimSuffix(tail, []byte("\n")), []byte("\n"))[1:]
+			}
+		}
+		if rule.matches(content, lines, tailLines) {
+			return "generated content rule " + rule.name, true
+		}
+	}
+	return "", false
+}
+
+func (rule contentRule) covers(name string) bool {
+	for _, ext := range rule.extensions {
+		if strings.HasSuffix(name, strings.ToLower(ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches runs the rule over content, split into lines, and the last lines of the file. A pattern searching the
+// whole file runs over the content at once so it can span lines, otherwise it runs over each searched line.
+// Line lengths and whole file patterns only see content when it was cut short.
+func (rule contentRule) matches(content []byte, lines, tailLines [][]byte) bool {
+	if rule.minAverageLineLength > 0 {
+		if len(lines) == 0 || len(content)/len(lines) <= int(rule.minAverageLineLength) {
+			return false
+		}
+		if rule.pattern == nil {
+			return true
+		}
+	}
+	if rule.lines == 0 {
+		return rule.pattern.Match(content)
+	}
+	searched := lines
+	if rule.lines > 0 && int(rule.lines) < len(lines) {
+		searched = lines[:rule.lines]
+	} else if rule.lines < 0 {
+		searched = tailLines
+		if int(-rule.lines) < len(tailLines) {
+			searched = tailLines[len(tailLines)+int(rule.lines):]
+		}
+	}
+	for _, line := range searched {
+		if rule.pattern.Match(line) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package analyze
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+)
+
+// Result is one file analyzed by Run, or the error reading it
+type Result struct {
+	File
+	Size int64
+	Err  error
+}
+
+// Run walks dir, a slash separated path relative to the root or empty for the whole tree, and analyzes its files
+// on concurrency workers, streaming a result for every file. Only concurrency paths and results are buffered, so
+// memory stays bounded however large the tree is.
+// The results channel is closed once every worker is done, then the walk error, if any, is sent on the error
+// channel, which is closed too. Cancelling ctx stops the walk and the workers, the error is then ctx.Err().
+func (a *Analyzer) Run(ctx context.Context, dir string, concurrency int) (<-chan Result, <-chan error) {
+	concurrency = max(concurrency, 1)
+	names := make(chan string, concurrency)
+	results := make(chan Result, concurrency)
+	errc := make(chan error, 1)
+
+	var walkErr error
+	go func() {
+		defer close(names)
+		walkErr = Walk(a.root, dir, func(name string, _ fs.DirEntry) error {
+			select {
+			case names <- name:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				if ctx.Err() != nil {
+					// keep draining so the walk is not left blocked on a full channel
+					continue
+				}
+				file, size, err := a.Read(name)
+				if err != nil {
+					file.Path = name
+				}
+				select {
+				case results <- Result{File: file, Size: size, Err: err}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+		// the walk is over once names is closed and drained, so walkErr is settled here
+		if walkErr != nil {
+			errc <- walkErr
+		} else if err := ctx.Err(); err != nil {
+			errc <- err
+		}
+		close(errc)
+	}()
+	return results, errc
+}
@@ -0,0 +1,145 @@
+package analyze
+
+import (
+	"sort"
+
+	"github.com/caner-cetin/seer/pkg/db"
+)
+
+// Reasons a file is left out of Stats
+const (
+	ExcludedBinary        = "binary"
+	ExcludedVendored      = "vendored"
+	ExcludedGenerated     = "generated"
+	ExcludedDocumentation = "documentation"
+	ExcludedUnknown       = "unknown"
+	ExcludedUndetectable  = "undetectable"
+)
+
+// StatsOptions chooses which files count, by default the vendored, generated and documentation files Linguist
+// leaves out are left out here too
+type StatsOptions struct {
+	Vendored      bool
+	Generated     bool
+	Documentation bool
+}
+
+// LanguageStats is how much of a tree is written in one language, or in the languages of one group
+type LanguageStats struct {
+	Name  string `json:"name" yaml:"name"`
+	Files int    `json:"files" yaml:"files"`
+	Bytes int64  `json:"bytes" yaml:"bytes"`
+	// Percent is the share of the bytes of every counted file, as on GitHub's language bar
+	Percent float64 `json:"percent" yaml:"percent"`
+	// Languages are the members of a group with files, biggest first, set when any member is not the group itself
+	Languages []LanguageStats `json:"languages,omitempty" yaml:"languages,omitempty"`
+}
+
+// Stats are the languages of a tree rolled up by group, biggest first
+type Stats struct {
+	Languages []LanguageStats `json:"languages" yaml:"languages"`
+	Files     int             `json:"files" yaml:"files"`
+	Bytes     int64           `json:"bytes" yaml:"bytes"`
+	// Excluded counts the files left out by reason
+	Excluded map[string]int `json:"excluded" yaml:"excluded"`
+}
+
+// Tally adds up analyzed files into Stats, it is not safe for concurrent use
+type Tally struct {
+	opts     StatsOptions
+	names    map[int32]string
+	rollup   db.LanguageRollup
+	files    map[int32]int
+	bytes    map[int32]int64
+	excluded map[string]int
+}
+
+// NewTally returns an empty tally rolling languages up along their parent_id
+func NewTally(languages []db.Language, opts StatsOptions) *Tally {
+	t := &Tally{
+		opts:     opts,
+		names:    make(map[int32]string, len(languages)),
+		rollup:   db.NewLanguageRollup(languages),
+		files:    make(map[int32]int),
+		bytes:    make(map[int32]int64),
+		excluded: make(map[string]int),
+	}
+	for _, lang := range languages {
+		t.names[lang.LanguageID] = lang.Name
+	}
+	return t
+}
+
+// Add counts a file of size bytes, or the reason it is left out
+func (t *Tally) Add(f File, size int64) {
+	best, ok := f.Language()
+	switch {
+	case f.Content.Binary && !ok:
+		t.excluded[ExcludedBinary]++
+	case f.Vendored.Set && !t.opts.Vendored:
+		t.excluded[ExcludedVendored]++
+	case f.Generated.Set && !t.opts.Generated:
+		t.excluded[ExcludedGenerated]++
+	case f.Documentation.Set && !t.opts.Documentation:
+		t.excluded[ExcludedDocumentation]++
+	case !ok:
+		t.excluded[ExcludedUnknown]++
+	case !f.Detectable.Set:
+		t.excluded[ExcludedUndetectable]++
+	default:
+		t.files[best.LanguageID]++
+		t.bytes[best.LanguageID] += size
+	}
+}
+
+// Stats returns the counted languages rolled up by group
+func (t *Tally) Stats() *Stats {
+	stats := &Stats{Excluded: t.excluded}
+	groups := make(map[int32]*LanguageStats)
+	for id, files := range t.files {
+		stats.Files += files
+		stats.Bytes += t.bytes[id]
+		root := t.rollup.Root(id)
+		group, ok := groups[root]
+		if !ok {
+			group = &LanguageStats{Name: t.names[root]}
+			groups[root] = group
+		}
+		group.Files += files
+		group.Bytes += t.bytes[id]
+		group.Languages = append(group.Languages, LanguageStats{Name: t.names[id], Files: files, Bytes: t.bytes[id]})
+	}
+	for root, group := range groups {
+		if len(group.Languages) == 1 && group.Languages[0].Name == t.names[root] {
+			group.Languages = nil
+		}
+		for i := range group.Languages {
+			group.Languages[i].Percent = percent(group.Languages[i].Bytes, stats.Bytes)
+		}
+		sortLanguageStats(group.Languages)
+		group.Percent = percent(group.Bytes, stats.Bytes)
+		stats.Languages = append(stats.Languages, *group)
+	}
+	sortLanguageStats(stats.Languages)
+	if stats.Languages == nil {
+		stats.Languages = []LanguageStats{}
+	}
+	return stats
+}
+
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// sortLanguageStats orders by bytes, biggest first, then by name
+func sortLanguageStats(languages []LanguageStats) {
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Bytes != languages[j].Bytes {
+			return languages[i].Bytes > languages[j].Bytes
+		}
+		return languages[i].Name < languages[j].Name
+	})
+}
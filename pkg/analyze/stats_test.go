@@ -0,0 +1,103 @@
+package analyze
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/caner-cetin/seer/pkg/detect"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var tallyLanguages = []db.Language{
+	{LanguageID: 1, Name: "JavaScript"},
+	{LanguageID: 2, Name: "JSX", ParentID: pgtype.Int4{Int32: 1, Valid: true}},
+	{LanguageID: 3, Name: "Go"},
+	{LanguageID: 4, Name: "Go Module", ParentID: pgtype.Int4{Int32: 3, Valid: true}},
+}
+
+// tallyFile is a detectable text file in the language with id
+func tallyFile(id int32) File {
+	return File{
+		Result:     detect.Result{Candidates: []detect.Candidate{{LanguageID: id}}},
+		Detectable: Mark{Set: true},
+	}
+}
+
+func TestTally(t *testing.T) {
+	tally := NewTally(tallyLanguages, StatsOptions{})
+	tally.Add(tallyFile(1), 300)
+	tally.Add(tallyFile(2), 100)
+	tally.Add(tallyFile(3), 600)
+
+	vendored := tallyFile(3)
+	vendored.Vendored = Mark{Set: true}
+	tally.Add(vendored, 1000)
+	generated := tallyFile(1)
+	generated.Generated = Mark{Set: true}
+	tally.Add(generated, 1000)
+	documentation := tallyFile(1)
+	documentation.Documentation = Mark{Set: true}
+	tally.Add(documentation, 1000)
+	undetectable := tallyFile(4)
+	undetectable.Detectable = Mark{}
+	tally.Add(undetectable, 1000)
+	tally.Add(File{}, 1000)
+	tally.Add(File{Result: detect.Result{Content: detect.ContentType{Binary: true}}}, 1000)
+
+	want := &Stats{
+		Languages: []LanguageStats{
+			{Name: "Go", Files: 1, Bytes: 600, Percent: 60},
+			{Name: "JavaScript", Files: 2, Bytes: 400, Percent: 40, Languages: []LanguageStats{
+				{Name: "JavaScript", Files: 1, Bytes: 300, Percent: 30},
+				{Name: "JSX", Files: 1, Bytes: 100, Percent: 10},
+			}},
+		},
+		Files: 3,
+		Bytes: 1000,
+		Excluded: map[string]int{
+			ExcludedVendored:      1,
+			ExcludedGenerated:     1,
+			ExcludedDocumentation: 1,
+			ExcludedUndetectable:  1,
+			ExcludedUnknown:       1,
+			ExcludedBinary:        1,
+		},
+	}
+	if got := tally.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTallyOptions(t *testing.T) {
+	tally := NewTally(tallyLanguages, StatsOptions{Vendored: true, Generated: true, Documentation: true})
+	vendored := tallyFile(3)
+	vendored.Vendored = Mark{Set: true}
+	vendored.Generated = Mark{Set: true}
+	vendored.Documentation = Mark{Set: true}
+	tally.Add(vendored, 100)
+
+	stats := tally.Stats()
+	if stats.Files != 1 || stats.Bytes != 100 || len(stats.Excluded) != 0 {
+		t.Errorf("Stats() = %+v, want the vendored, generated and documentation file counted", stats)
+	}
+}
+
+func TestTallyEmpty(t *testing.T) {
+	tally := NewTally(tallyLanguages, StatsOptions{})
+	tally.Add(File{}, 100)
+	stats := tally.Stats()
+	if stats.Languages == nil || len(stats.Languages) != 0 {
+		t.Errorf("Stats().Languages = %#v, want an empty list", stats.Languages)
+	}
+	if stats.Files != 0 || stats.Bytes != 0 {
+		t.Errorf("Stats() counted %d files and %d bytes, want none", stats.Files, stats.Bytes)
+	}
+
+	// files of zero bytes are counted without dividing by zero
+	tally.Add(tallyFile(3), 0)
+	stats = tally.Stats()
+	if len(stats.Languages) != 1 || stats.Languages[0].Percent != 0 {
+		t.Errorf("Stats().Languages = %+v, want Go at 0%%", stats.Languages)
+	}
+}
@@ -0,0 +1,53 @@
+package analyze
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Walk calls fn for every regular file under dir that git would not ignore, with its slash separated path
+// relative to root. dir is a slash separated path relative to root, empty to walk the whole tree, so that the
+// .gitignore files between root and dir still apply. .git directories, symbolic links and paths ignored by
+// .gitignore are skipped, and so are directories that cannot be read, with a warning.
+func Walk(root, dir string, fn func(name string, d fs.DirEntry) error) error {
+	ignore := NewIgnore(root)
+	start := filepath.Join(root, filepath.FromSlash(dir))
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == start {
+				return err
+			}
+			log.Warn().Err(err).Str("path", p).Msg("skipping unreadable path")
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if p == start {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || ignore.Ignored(name, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// a .git file points a submodule or worktree at its repository
+		if !d.Type().IsRegular() || d.Name() == ".git" || ignore.Ignored(name, false) {
+			return nil
+		}
+		return fn(name, d)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", start, err)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Catalogs accepted by LoadCatalog
+const (
+	CatalogTokei = "tokei"
+	CatalogScc   = "scc"
+)
+
+// Catalogs lists the catalog formats ImportCatalog understands
+var Catalogs = []string{CatalogTokei, CatalogScc}
+
+// CatalogEntry is the comment and string syntax of one language in a tokei or scc catalog
+type CatalogEntry struct {
+	// Name the catalog uses for the language
+	Name string
+	// Extensions without the leading dot, as both catalogs write them
+	Extensions    []string
+	Filenames     []string
+	LineComments  []string
+	BlockComments [][2]string
+	NestedBlocks  bool
+	Quotes        [][2]string
+}
+
+// CatalogMatch pairs a catalog entry with the languages row it was mapped onto
+type CatalogMatch struct {
+	Entry      CatalogEntry
+	LanguageID int32
+	Language   string
+	// How the entry was matched, name, alias or extension
+	By string
+}
+
+// CatalogUnmatched is a catalog entry that could not be mapped onto a single language
+type CatalogUnmatched struct {
+	Entry  CatalogEntry
+	Reason string
+}
+
+// CatalogImportSummary holds the outcome of ImportCatalog
+type CatalogImportSummary struct {
+	Catalog   string
+	Matched   []CatalogMatch
+	Unmatched []CatalogUnmatched
+}
+
+// tokeiCatalog is the shape of tokei's languages.json
+type tokeiCatalog struct {
+	Languages map[string]struct {
+		Name              string      `json:"name"`
+		LineComment       []string    `json:"line_comment"`
+		MultiLineComments [][2]string `json:"multi_line_comments"`
+		Nested            bool        `json:"nested"`
+		Quotes            [][2]string `json:"quotes"`
+		VerbatimQuotes    [][2]string `json:"verbatim_quotes"`
+		Extensions        []string    `json:"extensions"`
+		Filenames         []string    `json:"filenames"`
+	} `json:"languages"`
+}
+
+// sccCatalog is the shape of scc's languages.json
+type sccCatalog map[string]struct {
+	LineComment     []string    `json:"line_comment"`
+	MultiLine       [][2]string `json:"multi_line"`
+	NestedMultiLine bool        `json:"nestedmultiline"`
+	Quotes          []struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	} `json:"quotes"`
+	Extensions []string `json:"extensions"`
+	Filenames  []string `json:"filenames"`
+}
+
+// tokeiUnescape undoes the escaping tokei applies to tokens, its languages.json is rendered into Rust string literals
+var tokeiUnescape = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
+// LoadCatalog reads a tokei or scc languages.json from a local file, entries are returned sorted by name
+func LoadCatalog(path, catalog string) ([]CatalogEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var entries []CatalogEntry
+	switch catalog {
+	case CatalogTokei:
+		var c tokeiCatalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+		}
+		for key, lang := range c.Languages {
+			entry := CatalogEntry{
+				Name:         key,
+				Extensions:   lang.Extensions,
+				Filenames:    lang.Filenames,
+				NestedBlocks: lang.Nested,
+			}
+			if lang.Name != "" {
+				entry.Name = lang.Name
+			}
+			for _, token := range lang.LineComment {
+				entry.LineComments = append(entry.LineComments, tokeiUnescape.Replace(token))
+			}
+			for _, pair := range lang.MultiLineComments {
+				entry.BlockComments = append(entry.BlockComments, [2]string{tokeiUnescape.Replace(pair[0]), tokeiUnescape.Replace(pair[1])})
+			}
+			for _, pair := range append(lang.Quotes, lang.VerbatimQuotes...) {
+				entry.Quotes = append(entry.Quotes, [2]string{tokeiUnescape.Replace(pair[0]), tokeiUnescape.Replace(pair[1])})
+			}
+			entries = append(entries, entry)
+		}
+	case CatalogScc:
+		var c sccCatalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+		}
+		for name, lang := range c {
+			entry := CatalogEntry{
+				Name:          name,
+				Extensions:    lang.Extensions,
+				Filenames:     lang.Filenames,
+				LineComments:  lang.LineComment,
+				BlockComments: lang.MultiLine,
+				NestedBlocks:  lang.NestedMultiLine,
+			}
+			for _, q := range lang.Quotes {
+				entry.Quotes = append(entry.Quotes, [2]string{q.Start, q.End})
+			}
+			entries = append(entries, entry)
+		}
+	default:
+		return nil, fmt.Errorf("unknown catalog %q, expected one of %s", catalog, strings.Join(Catalogs, ", "))
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no languages found in %s", path)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// MatchCatalog maps catalog entries onto languages by name, then alias, then extension.
+// Names and aliases compare case insensitively. An extension only matches when every extension of
+// the entry that is known points at the same single language, anything else is reported as unmatched.
+func MatchCatalog(entries []CatalogEntry, languages []Language) ([]CatalogMatch, []CatalogUnmatched) {
+	byName := make(map[string]Language, len(languages))
+	byAlias := make(map[string]Language)
+	byExtension := make(map[string][]Language)
+	for _, lang := range languages {
+		byName[strings.ToLower(lang.Name)] = lang
+		for _, alias := range lang.Aliases {
+			byAlias[strings.ToLower(alias)] = lang
+		}
+		for _, ext := range lang.Extensions {
+			ext = strings.ToLower(ext)
+			byExtension[ext] = append(byExtension[ext], lang)
+		}
+	}
+
+	var matched []CatalogMatch
+	var unmatched []CatalogUnmatched
+	for _, entry := range entries {
+		key := strings.ToLower(entry.Name)
+		if lang, ok := byName[key]; ok {
+			matched = append(matched, CatalogMatch{Entry: entry, LanguageID: lang.LanguageID, Language: lang.Name, By: "name"})
+			continue
+		}
+		if lang, ok := byAlias[key]; ok {
+			matched = append(matched, CatalogMatch{Entry: entry, LanguageID: lang.LanguageID, Language: lang.Name, By: "alias"})
+			continue
+		}
+		candidates := make(map[int32]Language)
+		for _, ext := range entry.Extensions {
+			for _, lang := range byExtension["."+strings.ToLower(strings.TrimPrefix(ext, "."))] {
+				candidates[lang.LanguageID] = lang
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			unmatched = append(unmatched, CatalogUnmatched{Entry: entry, Reason: "no language shares its name, an alias or an extension"})
+		case 1:
+			for _, lang := range candidates {
+				matched = append(matched, CatalogMatch{Entry: entry, LanguageID: lang.LanguageID, Language: lang.Name, By: "extension"})
+			}
+		default:
+			names := make([]string, 0, len(candidates))
+			for _, lang := range candidates {
+				names = append(names, lang.Name)
+			}
+			sort.Strings(names)
+			unmatched = append(unmatched, CatalogUnmatched{Entry: entry, Reason: "extensions are shared by " + strings.Join(names, ", ")})
+		}
+	}
+
+	// two entries landing on the same language would overwrite each other, the closest match keeps it
+	rank := map[string]int{"name": 0, "alias": 1, "extension": 2}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].LanguageID != matched[j].LanguageID {
+			return matched[i].LanguageID < matched[j].LanguageID
+		}
+		return rank[matched[i].By] < rank[matched[j].By]
+	})
+	deduped := matched[:0]
+	for _, m := range matched {
+		if len(deduped) > 0 && deduped[len(deduped)-1].LanguageID == m.LanguageID {
+			unmatched = append(unmatched, CatalogUnmatched{Entry: m.Entry, Reason: fmt.Sprintf("%s is already matched by %s", m.Language, deduped[len(deduped)-1].Entry.Name)})
+			continue
+		}
+		deduped = append(deduped, m)
+	}
+	sort.Slice(unmatched, func(i, j int) bool { return unmatched[i].Entry.Name < unmatched[j].Entry.Name })
+	return deduped, unmatched
+}
+
+// ImportCatalog loads a tokei or scc languages.json from path, maps it onto the languages table and writes
+// the comment and string syntax of every matched language in a single transaction. Languages the catalog
+// does not match keep the syntax of earlier imports.
+func ImportCatalog(ctx context.Context, conn TxBeginner, path, catalog string) (*CatalogImportSummary, error) {
+	entries, err := LoadCatalog(path, catalog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s catalog: %w", catalog, err)
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback catalog import transaction")
+		}
+	}()
+	q := New(tx)
+
+	languages, err := q.GetLanguages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get languages: %w", err)
+	}
+	matched, unmatched := MatchCatalog(entries, languages)
+	for _, m := range matched {
+		params := UpsertLanguageSyntaxParams{
+			LanguageID:          m.LanguageID,
+			Catalog:             catalog,
+			CatalogName:         m.Entry.Name,
+			LineComments:        nonNilStrings(m.Entry.LineComments),
+			BlockCommentStarts:  []string{},
+			BlockCommentEnds:    []string{},
+			NestedBlockComments: m.Entry.NestedBlocks,
+			QuoteStarts:         []string{},
+			QuoteEnds:           []string{},
+		}
+		for _, pair := range m.Entry.BlockComments {
+			params.BlockCommentStarts = append(params.BlockCommentStarts, pair[0])
+			params.BlockCommentEnds = append(params.BlockCommentEnds, pair[1])
+		}
+		for _, pair := range m.Entry.Quotes {
+			params.QuoteStarts = append(params.QuoteStarts, pair[0])
+			params.QuoteEnds = append(params.QuoteEnds, pair[1])
+		}
+		if err := q.UpsertLanguageSyntax(ctx, params); err != nil {
+			return nil, fmt.Errorf("failed to write syntax of %s: %w", m.Language, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit catalog import: %w", err)
+	}
+	return &CatalogImportSummary{Catalog: catalog, Matched: matched, Unmatched: unmatched}, nil
+}
+
+// nonNilStrings returns s, or an empty slice when s is nil, since the syntax columns are NOT NULL
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Language change kinds reported by DiffLanguages
+const (
+	LanguageAdded   = "added"
+	LanguageChanged = "changed"
+	LanguageRemoved = "removed"
+)
+
+// FieldChange is a single differing field between two versions of a language.
+// Scalar fields fill Old and New, list fields fill Added and Removed, or Old and New when only the order changed.
+type FieldChange struct {
+	Field   string   `json:"field"`
+	Old     any      `json:"old,omitempty"`
+	New     any      `json:"new,omitempty"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// LanguageChange describes how a single language, keyed by language_id, differs between two sets
+type LanguageChange struct {
+	LanguageID int32         `json:"language_id"`
+	Name       string        `json:"name"`
+	Kind       string        `json:"kind"`
+	Fields     []FieldChange `json:"fields,omitempty"`
+}
+
+// DiffLanguages compares current against candidate keyed by language_id, returning the changes sorted by name.
+// Rows missing from candidate are reported as removed when SyncLanguages with opts would delete them.
+func DiffLanguages(current, candidate []Language, opts SyncOptions) []LanguageChange {
+	existing := make(map[int32]Language, len(current))
+	for _, lang := range current {
+		existing[lang.LanguageID] = lang
+	}
+	var changes []LanguageChange
+	for _, lang := range candidate {
+		old, ok := existing[lang.LanguageID]
+		if !ok {
+			changes = append(changes, LanguageChange{LanguageID: lang.LanguageID, Name: lang.Name, Kind: LanguageAdded})
+			continue
+		}
+		delete(existing, lang.LanguageID)
+		if fields := old.Diff(lang); len(fields) > 0 {
+			changes = append(changes, LanguageChange{LanguageID: lang.LanguageID, Name: lang.Name, Kind: LanguageChanged, Fields: fields})
+		}
+	}
+	for _, lang := range existing {
+		if opts.keeps(lang) {
+			continue
+		}
+		changes = append(changes, LanguageChange{LanguageID: lang.LanguageID, Name: lang.Name, Kind: LanguageRemoved})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].LanguageID < changes[j].LanguageID
+	})
+	return changes
+}
+
+// Diff returns the fields that differ from l to o, ignoring the database assigned ID, ingest run and the parent_id derived from group
+func (l Language) Diff(o Language) []FieldChange {
+	var fields []FieldChange
+	scalar := func(field string, before, after any) {
+		if before != after {
+			fields = append(fields, FieldChange{Field: field, Old: before, New: after})
+		}
+	}
+	list := func(field string, before, after []string) {
+		if change, ok := diffList(field, before, after); ok {
+			fields = append(fields, change)
+		}
+	}
+	scalar("name", l.Name, o.Name)
+	scalar("fs_name", textValue(l.FsName), textValue(o.FsName))
+	scalar("type", typeValue(l.Type), typeValue(o.Type))
+	list("aliases", l.Aliases, o.Aliases)
+	scalar("ace_mode", textValue(l.AceMode), textValue(o.AceMode))
+	scalar("codemirror_mode", textValue(l.CodemirrorMode), textValue(o.CodemirrorMode))
+	scalar("codemirror_mime_type", textValue(l.CodemirrorMimeType), textValue(o.CodemirrorMimeType))
+	scalar("wrap", boolValue(l.Wrap), boolValue(o.Wrap))
+	list("extensions", l.Extensions, o.Extensions)
+	list("filenames", l.Filenames, o.Filenames)
+	list("interpreters", l.Interpreters, o.Interpreters)
+	scalar("language_id", l.LanguageID, o.LanguageID)
+	scalar("color", textValue(l.Color), textValue(o.Color))
+	scalar("tm_scope", textValue(l.TmScope), textValue(o.TmScope))
+	scalar("group", textValue(l.Group), textValue(o.Group))
+	scalar("custom", l.Custom, o.Custom)
+	scalar("overlay", textValue(l.Overlay), textValue(o.Overlay))
+	return fields
+}
+
+func diffList(field string, before, after []string) (FieldChange, bool) {
+	if slices.Equal(before, after) {
+		return FieldChange{}, false
+	}
+	change := FieldChange{Field: field}
+	for _, v := range after {
+		if !slices.Contains(before, v) {
+			change.Added = append(change.Added, v)
+		}
+	}
+	for _, v := range before {
+		if !slices.Contains(after, v) {
+			change.Removed = append(change.Removed, v)
+		}
+	}
+	if change.Added == nil && change.Removed == nil {
+		// same entries in a different order, which matters for the primary extension
+		change.Old = before
+		change.New = after
+	}
+	return change, true
+}
+
+// textValue returns nil for NULL so that NULL and the empty string are told apart
+func textValue(t pgtype.Text) any {
+	if !t.Valid {
+		return nil
+	}
+	return t.String
+}
+
+func typeValue(t NullLanguageType) any {
+	if !t.Valid {
+		return nil
+	}
+	return string(t.LanguageType)
+}
+
+func boolValue(b pgtype.Bool) any {
+	if !b.Valid {
+		return nil
+	}
+	return b.Bool
+}
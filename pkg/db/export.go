@@ -0,0 +1,142 @@
+package db
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Export formats accepted by ExportLanguages
+const (
+	ExportYAML = "yaml"
+	ExportJSON = "json"
+	ExportCSV  = "csv"
+)
+
+// ExportFormats lists the formats accepted by ExportLanguages
+var ExportFormats = []string{ExportYAML, ExportJSON, ExportCSV}
+
+// csvListSeparator joins list fields in a single CSV cell, none of the Linguist values contain it
+const csvListSeparator = "|"
+
+// ToNonPgType converts a Language back to its languages.yml form, NULL fields become zero values
+func (l Language) ToNonPgType() LanguageNonPgtype {
+	lang := LanguageNonPgtype{
+		Name:               l.Name,
+		FsName:             l.FsName.String,
+		Aliases:            l.Aliases,
+		AceMode:            l.AceMode.String,
+		CodemirrorMode:     l.CodemirrorMode.String,
+		CodemirrorMimeType: l.CodemirrorMimeType.String,
+		Wrap:               l.Wrap.Valid && l.Wrap.Bool,
+		Extensions:         l.Extensions,
+		Filenames:          l.Filenames,
+		Interpreters:       l.Interpreters,
+		LanguageID:         l.LanguageID,
+		Color:              l.Color.String,
+		TmScope:            l.TmScope.String,
+		Group:              l.Group.String,
+		Custom:             l.Custom,
+		Overlay:            l.Overlay.String,
+	}
+	if l.Type.Valid {
+		lang.Type = string(l.Type.LanguageType)
+	}
+	return lang
+}
+
+// ExportLanguages writes languages to w sorted by name. yaml is the languages.yml shape accepted by LanguagesNonPgtype.Load,
+// json is an array of objects and csv has one row per language with list fields joined by csvListSeparator.
+// NULL and empty fields are left out of yaml and json, and left blank in csv.
+func ExportLanguages(w io.Writer, languages []Language, format string) error {
+	exported := make(LanguagesNonPgtype, 0, len(languages))
+	for _, lang := range languages {
+		exported = append(exported, lang.ToNonPgType())
+	}
+	sort.Slice(exported, func(i, j int) bool { return exported[i].Name < exported[j].Name })
+	switch format {
+	case ExportYAML:
+		return exportLanguagesYAML(w, exported)
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(exported); err != nil {
+			return fmt.Errorf("failed to encode languages: %w", err)
+		}
+		return nil
+	case ExportCSV:
+		return exportLanguagesCSV(w, exported)
+	default:
+		return fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+func exportLanguagesYAML(w io.Writer, languages LanguagesNonPgtype) error {
+	// languages.yml is keyed by name, so the name field itself is left out
+	byName := make(map[string]LanguageNonPgtype, len(languages))
+	for _, lang := range languages {
+		name := lang.Name
+		lang.Name = ""
+		byName[name] = lang
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(byName); err != nil {
+		return fmt.Errorf("failed to encode languages: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode languages: %w", err)
+	}
+	return nil
+}
+
+func exportLanguagesCSV(w io.Writer, languages LanguagesNonPgtype) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"language_id", "name", "fs_name", "type", "aliases", "ace_mode", "codemirror_mode", "codemirror_mime_type",
+		"wrap", "extensions", "filenames", "interpreters", "color", "tm_scope", "group", "custom",
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+	flag := func(b bool) string {
+		if !b {
+			return ""
+		}
+		return strconv.FormatBool(b)
+	}
+	for _, lang := range languages {
+		record := []string{
+			strconv.FormatInt(int64(lang.LanguageID), 10),
+			lang.Name,
+			lang.FsName,
+			lang.Type,
+			strings.Join(lang.Aliases, csvListSeparator),
+			lang.AceMode,
+			lang.CodemirrorMode,
+			lang.CodemirrorMimeType,
+			flag(lang.Wrap),
+			strings.Join(lang.Extensions, csvListSeparator),
+			strings.Join(lang.Filenames, csvListSeparator),
+			strings.Join(lang.Interpreters, csvListSeparator),
+			lang.Color,
+			lang.TmScope,
+			lang.Group,
+			flag(lang.Custom),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv row for %s: %w", lang.Name, err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FetchOptions tunes how http(s) sources are downloaded
+type FetchOptions struct {
+	// Timeout bounds a single download attempt, zero disables it
+	Timeout time.Duration
+	// Retries is the number of extra attempts made after a failed download
+	Retries int
+	// Backoff is the delay before the first retry, doubled before each following one
+	Backoff time.Duration
+	// CacheDir keeps downloaded files for conditional requests, empty disables the cache
+	CacheDir string
+}
+
+// DefaultFetchOptions returns the options used when nothing is configured, caching under DefaultCacheDir.
+// A single download where every attempt times out gives up after about 48 seconds. Downloads sharing one context,
+// as the sources of migrate share --timeout, are budgeted against its deadline instead: every attempt is bounded
+// by it and no retry is made once the deadline leaves no time past the backoff.
+func DefaultFetchOptions() FetchOptions {
+	return FetchOptions{
+		Timeout:  15 * time.Second,
+		Retries:  2,
+		Backoff:  time.Second,
+		CacheDir: DefaultCacheDir(),
+	}
+}
+
+// DefaultCacheDir returns seer/linguist under the user cache directory ($XDG_CACHE_HOME or ~/.cache on Linux),
+// or an empty string, disabling the cache, when there is no such directory
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Warn().Err(err).Msg("no user cache directory, linguist downloads will not be cached")
+		return ""
+	}
+	return filepath.Join(dir, "seer", "linguist")
+}
+
+// cacheEntry is stored next to a cached body, holding the validators sent on the next request
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// errRetryable marks download failures that are worth another attempt
+var errRetryable = errors.New("retryable")
+
+// fetchRemoteSource downloads src.Path, retrying with backoff and revalidating against the cache when there is one.
+// Retries stop early once the deadline of ctx would pass during the backoff, so the total time never exceeds it.
+func fetchRemoteSource(ctx context.Context, src *Source, opts FetchOptions) (*Source, error) {
+	client := &http.Client{Timeout: opts.Timeout}
+	backoff := opts.Backoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fetchRemoteSourceOnce(ctx, client, src, opts.CacheDir)
+		if err == nil || !errors.Is(err, errRetryable) || attempt >= opts.Retries {
+			break
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+			return nil, fmt.Errorf("gave up fetching %s, the deadline leaves no time for another attempt: %w", src.Path, err)
+		}
+		log.Warn().Err(err).Int("attempt", attempt+1).Dur("backoff", backoff).Msg("linguist download failed, retrying")
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up fetching %s: %w", src.Path, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	if err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+func fetchRemoteSourceOnce(ctx context.Context, client *http.Client, src *Source, cacheDir string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.Path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	cached, hasCache := readCacheEntry(cacheDir, src.Path)
+	if hasCache {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to get %s: %w", src.Path, err)
+		}
+		return fmt.Errorf("failed to get %s: %w: %w", src.Path, errRetryable, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Error().Err(err).Msg("failed to close response body")
+		}
+	}()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && hasCache:
+		data, err := os.ReadFile(cacheBodyPath(cacheDir, src.Path))
+		if err != nil {
+			return fmt.Errorf("server reported %s unchanged but the cached copy is unreadable: %w", src.Path, err)
+		}
+		log.Debug().Str("url", src.Path).Msg("linguist source not modified, using cached copy")
+		src.Data = data
+		src.ETag = cached.ETag
+		src.Cached = true
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("unexpected status %s from %s: %w", resp.Status, src.Path, errRetryable)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, src.Path)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body from %s: %w: %w", src.Path, errRetryable, err)
+	}
+	src.Data = data
+	src.ETag = resp.Header.Get("ETag")
+	writeCacheEntry(cacheDir, cacheEntry{
+		URL:          src.Path,
+		ETag:         src.ETag,
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, data)
+	return nil
+}
+
+// cacheKey names the cache files of a URL
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:16])
+}
+
+func cacheBodyPath(cacheDir, url string) string {
+	return filepath.Join(cacheDir, cacheKey(url)+".body")
+}
+
+func cacheEntryPath(cacheDir, url string) string {
+	return filepath.Join(cacheDir, cacheKey(url)+".json")
+}
+
+func readCacheEntry(cacheDir, url string) (cacheEntry, bool) {
+	var entry cacheEntry
+	if cacheDir == "" {
+		return entry, false
+	}
+	data, err := os.ReadFile(cacheEntryPath(cacheDir, url))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return entry, false
+	}
+	if _, err := os.Stat(cacheBodyPath(cacheDir, url)); err != nil {
+		return entry, false
+	}
+	return entry, entry.ETag != "" || entry.LastModified != ""
+}
+
+// writeCacheEntry stores a downloaded body, failures only cost the next conditional request so they are logged
+func writeCacheEntry(cacheDir string, entry cacheEntry, data []byte) {
+	if cacheDir == "" || (entry.ETag == "" && entry.LastModified == "") {
+		return
+	}
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		log.Warn().Err(err).Str("dir", cacheDir).Msg("failed to create linguist cache directory")
+		return
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to marshal linguist cache entry")
+		return
+	}
+	// body first, so that a crash in between leaves a stale body without validators rather than the opposite
+	if err := writeFileAtomic(cacheBodyPath(cacheDir, entry.URL), data); err != nil {
+		log.Warn().Err(err).Str("url", entry.URL).Msg("failed to cache linguist source")
+		return
+	}
+	if err := writeFileAtomic(cacheEntryPath(cacheDir, entry.URL), meta); err != nil {
+		log.Warn().Err(err).Str("url", entry.URL).Msg("failed to cache linguist source validators")
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", tmp, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultGrammarsPath is the upstream Linguist grammars.yml
+const DefaultGrammarsPath = "https://raw.githubusercontent.com/github/linguist/master/grammars.yml"
+
+// GrammarsNonPgtype maps a grammar repository to the TextMate scopes it provides, the shape of Linguist's grammars.yml
+type GrammarsNonPgtype map[string][]string
+
+// GrammarsSyncSummary holds the number of scopes written by SyncGrammars and the languages naming none of them
+type GrammarsSyncSummary struct {
+	Scopes        int
+	MissingScopes []GetLanguagesWithUnknownScopeRow
+}
+
+// Load reads grammars.yml from path, accepting the same forms as LanguagesNonPgtype.Load except SourceEmbedded:
+// seer bundles no grammars.yml, since the data it is built from does not record which repository provides a scope.
+// A scope claimed by two repositories fails the load.
+func (g *GrammarsNonPgtype) Load(ctx context.Context, path string, fetch FetchOptions) (*Source, error) {
+	if path == SourceEmbedded {
+		return nil, errors.New("seer has no embedded grammars.yml snapshot, point the grammars source at a local copy or upstream")
+	}
+	src, err := fetchSource(ctx, path, nil, fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch linguist grammars: %w", err)
+	}
+	var loaded GrammarsNonPgtype
+	if err := yaml.Unmarshal(src.Data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", src.Path, err)
+	}
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("no grammars found in %s", src.Path)
+	}
+	owners := make(map[string]string)
+	var problems []error
+	for _, repository := range loaded.Repositories() {
+		for _, scope := range loaded[repository] {
+			if other, ok := owners[scope]; ok {
+				problems = append(problems, fmt.Errorf("scope %s is provided by both %s and %s", scope, other, repository))
+				continue
+			}
+			owners[scope] = repository
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid grammars in %s: %w", src.Path, errors.Join(problems...))
+	}
+	*g = loaded
+	return src, nil
+}
+
+// Repositories returns the grammar repositories in sorted order
+func (g GrammarsNonPgtype) Repositories() []string {
+	repositories := make([]string, 0, len(g))
+	for repository := range g {
+		repositories = append(repositories, repository)
+	}
+	sort.Strings(repositories)
+	return repositories
+}
+
+// Scopes returns the set of scopes provided by any grammar
+func (g GrammarsNonPgtype) Scopes() map[string]bool {
+	scopes := make(map[string]bool)
+	for _, provided := range g {
+		for _, scope := range provided {
+			scopes[scope] = true
+		}
+	}
+	return scopes
+}
+
+// SyncGrammars replaces the grammars table with g in a single transaction, then reports the languages
+// whose tm_scope is not provided by any grammar. Those are only reported, since an organization may
+// highlight them with grammars Linguist does not know about.
+func SyncGrammars(ctx context.Context, conn TxBeginner, g GrammarsNonPgtype) (*GrammarsSyncSummary, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback grammars sync transaction")
+		}
+	}()
+	q := New(tx)
+
+	var params CreateGrammarsParams
+	for _, repository := range g.Repositories() {
+		for _, scope := range g[repository] {
+			params.Scopes = append(params.Scopes, scope)
+			params.Repositories = append(params.Repositories, repository)
+		}
+	}
+	if err := q.DeleteGrammars(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete grammars: %w", err)
+	}
+	if err := q.CreateGrammars(ctx, params); err != nil {
+		return nil, fmt.Errorf("failed to insert grammars: %w", err)
+	}
+	missing, err := q.GetLanguagesWithUnknownScope(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check language scopes: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit grammars sync: %w", err)
+	}
+	return &GrammarsSyncSummary{Scopes: len(params.Scopes), MissingScopes: missing}, nil
+}
@@ -0,0 +1,38 @@
+package db
+
+// LanguageRollup maps a language_id to the language_id of its root ancestor, which statistics count it under
+type LanguageRollup map[int32]int32
+
+// NewLanguageRollup follows the parent_id of every language up to its root, languages without a parent are their own root
+func NewLanguageRollup(languages []Language) LanguageRollup {
+	parents := make(map[int32]int32, len(languages))
+	for _, lang := range languages {
+		if lang.ParentID.Valid {
+			parents[lang.LanguageID] = lang.ParentID.Int32
+		}
+	}
+	rollup := make(LanguageRollup, len(languages))
+	for _, lang := range languages {
+		root := lang.LanguageID
+		seen := map[int32]bool{root: true}
+		for {
+			parent, ok := parents[root]
+			if !ok || seen[parent] {
+				// a cycle cannot come out of Linguist data, stop at the last language before it repeats
+				break
+			}
+			seen[parent] = true
+			root = parent
+		}
+		rollup[lang.LanguageID] = root
+	}
+	return rollup
+}
+
+// Root returns the language_id languageID rolls up into, unknown ids roll up into themselves
+func (r LanguageRollup) Root(languageID int32) int32 {
+	if root, ok := r[languageID]; ok {
+		return root
+	}
+	return languageID
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHeuristicsPath is the upstream Linguist heuristics.yml
+const DefaultHeuristicsPath = "https://raw.githubusercontent.com/github/linguist/master/lib/linguist/heuristics.yml"
+
+//go:embed linguist/heuristics.yml
+var embeddedHeuristics []byte
+
+// StringList is a YAML value written either as a single string or as a list of strings
+type StringList []string
+
+// UnmarshalYAML accepts both a scalar and a sequence
+func (s *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var v string
+		if err := node.Decode(&v); err != nil {
+			return err
+		}
+		*s = StringList{v}
+		return nil
+	}
+	var v []string
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// HeuristicRuleNonPgtype is a single rule of a heuristics.yml disambiguation.
+// A rule sets at most one of Pattern, NegativePattern, NamedPattern and And, a rule setting none always matches.
+type HeuristicRuleNonPgtype struct {
+	// Languages picked when the rule matches, only set on top level rules
+	Language StringList `yaml:"language"`
+	// Ruby regular expressions, any of which has to match
+	Pattern StringList `yaml:"pattern"`
+	// Ruby regular expressions, none of which may match
+	NegativePattern StringList `yaml:"negative_pattern"`
+	// Key of HeuristicsNonPgtype.NamedPatterns that has to match
+	NamedPattern string `yaml:"named_pattern"`
+	// Rules that all have to match
+	And []HeuristicRuleNonPgtype `yaml:"and"`
+}
+
+// DisambiguationNonPgtype settles the language of files sharing one of its extensions, the first matching rule wins
+type DisambiguationNonPgtype struct {
+	Extensions []string                 `yaml:"extensions"`
+	Rules      []HeuristicRuleNonPgtype `yaml:"rules"`
+}
+
+// HeuristicsNonPgtype is the content of Linguist's heuristics.yml, without pgtypes
+type HeuristicsNonPgtype struct {
+	Disambiguations []DisambiguationNonPgtype `yaml:"disambiguations"`
+	NamedPatterns   map[string]StringList     `yaml:"named_patterns"`
+}
+
+// HeuristicsSyncSummary counts the rows written by SyncHeuristics
+type HeuristicsSyncSummary struct {
+	Disambiguations int
+	Rules           int
+	NamedPatterns   int
+}
+
+// Load reads heuristics.yml from path, accepting the same forms as LanguagesNonPgtype.Load.
+// Unlike languages, any invalid rule fails the whole load, since rules are only meaningful in order.
+func (h *HeuristicsNonPgtype) Load(ctx context.Context, path string, fetch FetchOptions) (*Source, error) {
+	src, err := fetchSource(ctx, path, embeddedHeuristics, fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch linguist heuristics: %w", err)
+	}
+	var loaded HeuristicsNonPgtype
+	if err := yaml.Unmarshal(src.Data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", src.Path, err)
+	}
+	if len(loaded.Disambiguations) == 0 {
+		return nil, fmt.Errorf("no disambiguations found in %s", src.Path)
+	}
+	if err := loaded.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid heuristics in %s: %w", src.Path, err)
+	}
+	*h = loaded
+	return src, nil
+}
+
+// Validate checks every disambiguation has extensions and rules, and every rule is well formed
+func (h HeuristicsNonPgtype) Validate() error {
+	var problems []error
+	for name, patterns := range h.NamedPatterns {
+		if len(patterns) == 0 {
+			problems = append(problems, fmt.Errorf("named pattern %s is empty", name))
+		}
+	}
+	for i, d := range h.Disambiguations {
+		where := fmt.Sprintf("disambiguation %d (%s)", i, strings.Join(d.Extensions, ", "))
+		if len(d.Extensions) == 0 {
+			problems = append(problems, fmt.Errorf("%s has no extensions", where))
+		}
+		for _, ext := range d.Extensions {
+			if !strings.HasPrefix(ext, ".") {
+				problems = append(problems, fmt.Errorf("%s: extension %q does not start with a dot", where, ext))
+			}
+		}
+		if len(d.Rules) == 0 {
+			problems = append(problems, fmt.Errorf("%s has no rules", where))
+		}
+		for j, rule := range d.Rules {
+			if len(rule.Language) == 0 {
+				problems = append(problems, fmt.Errorf("%s: rule %d has no language", where, j))
+			}
+			for _, err := range h.validateRule(rule) {
+				problems = append(problems, fmt.Errorf("%s: rule %d: %w", where, j, err))
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
+
+func (h HeuristicsNonPgtype) validateRule(rule HeuristicRuleNonPgtype) []error {
+	var problems []error
+	set := 0
+	for _, isSet := range []bool{len(rule.Pattern) > 0, len(rule.NegativePattern) > 0, rule.NamedPattern != "", len(rule.And) > 0} {
+		if isSet {
+			set++
+		}
+	}
+	if set > 1 {
+		problems = append(problems, fmt.Errorf("only one of pattern, negative_pattern, named_pattern and and may be set"))
+	}
+	for _, p := range slices.Concat(rule.Pattern, rule.NegativePattern) {
+		if p == "" {
+			problems = append(problems, fmt.Errorf("empty pattern"))
+		}
+	}
+	if rule.NamedPattern != "" {
+		if _, ok := h.NamedPatterns[rule.NamedPattern]; !ok {
+			problems = append(problems, fmt.Errorf("unknown named pattern %s", rule.NamedPattern))
+		}
+	}
+	for _, member := range rule.And {
+		if len(member.Language) > 0 {
+			problems = append(problems, fmt.Errorf("and members cannot set a language"))
+		}
+		problems = append(problems, h.validateRule(member)...)
+	}
+	return problems
+}
+
+// SyncHeuristics replaces the heuristic tables with h in a single transaction.
+// Rules have no stable identity to diff on, so the previous rows are dropped and h is written in full.
+func SyncHeuristics(ctx context.Context, conn TxBeginner, h HeuristicsNonPgtype) (*HeuristicsSyncSummary, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback heuristics sync transaction")
+		}
+	}()
+	q := New(tx)
+
+	if err := q.DeleteHeuristicDisambiguations(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete heuristic disambiguations: %w", err)
+	}
+	if err := q.DeleteHeuristicNamedPatterns(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete heuristic named patterns: %w", err)
+	}
+
+	summary := &HeuristicsSyncSummary{}
+	for name, patterns := range h.NamedPatterns {
+		if err := q.CreateHeuristicNamedPattern(ctx, CreateHeuristicNamedPatternParams{Name: name, Pattern: []string(patterns)}); err != nil {
+			return nil, fmt.Errorf("failed to insert named pattern %s: %w", name, err)
+		}
+		summary.NamedPatterns++
+	}
+	for i, d := range h.Disambiguations {
+		id, err := q.CreateHeuristicDisambiguation(ctx, CreateHeuristicDisambiguationParams{
+			Position:   int32(i), //nolint: gosec
+			Extensions: d.Extensions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert disambiguation for %s: %w", strings.Join(d.Extensions, ", "), err)
+		}
+		summary.Disambiguations++
+		for j, rule := range d.Rules {
+			n, err := insertHeuristicRule(ctx, q, id, pgtype.Int4{}, j, rule)
+			if err != nil {
+				return nil, fmt.Errorf("failed to insert rule %d for %s: %w", j, strings.Join(d.Extensions, ", "), err)
+			}
+			summary.Rules += n
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit heuristics sync: %w", err)
+	}
+	return summary, nil
+}
+
+// insertHeuristicRule writes rule and its and members, returning the number of rows written
+func insertHeuristicRule(ctx context.Context, q *Queries, disambiguationID int32, parent pgtype.Int4, position int, rule HeuristicRuleNonPgtype) (int, error) {
+	params := CreateHeuristicRuleParams{
+		DisambiguationID: disambiguationID,
+		ParentRuleID:     parent,
+		Position:         int32(position), //nolint: gosec
+		Languages:        []string(rule.Language),
+		Pattern:          []string(rule.Pattern),
+		NegativePattern:  []string(rule.NegativePattern),
+	}
+	if rule.NamedPattern != "" {
+		params.NamedPattern = pgtype.Text{String: rule.NamedPattern, Valid: true}
+	}
+	id, err := q.CreateHeuristicRule(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	written := 1
+	for i, member := range rule.And {
+		n, err := insertHeuristicRule(ctx, q, disambiguationID, pgtype.Int4{Int32: id, Valid: true}, i, member)
+		if err != nil {
+			return 0, err
+		}
+		written += n
+	}
+	return written, nil
+}
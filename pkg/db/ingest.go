@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultLanguagesPath is the upstream Linguist languages.yml
+const DefaultLanguagesPath = "https://raw.githubusercontent.com/github/linguist/master/lib/linguist/languages.yml"
+
+// IngestOptions configures IngestLanguages
+type IngestOptions struct {
+	// Path of languages.yml, see LanguagesNonPgtype.Load for the accepted forms
+	Path string
+	// Overlays are organization specific languages.yml files merged over Path, in order
+	Overlays []string
+	// Load tunes fetching and validating languages.yml
+	Load LoadOptions
+}
+
+// LoadLanguages loads languages.yml and applies the overlays over it. Entries skipped by a non-strict load
+// are logged, a load that yields no languages at all is refused since syncing an empty set would wipe the table.
+func LoadLanguages(ctx context.Context, opts IngestOptions) (LanguagesNonPgtype, *Source, error) {
+	var languages LanguagesNonPgtype
+	src, err := languages.Load(ctx, opts.Path, opts.Load)
+	if err != nil {
+		var loadErr *LanguageLoadError
+		if !errors.As(err, &loadErr) || opts.Load.Strict {
+			return nil, nil, fmt.Errorf("failed to load linguist languages: %w", err)
+		}
+		loadErr.LogSkipped()
+	}
+	if len(languages) == 0 {
+		return nil, nil, fmt.Errorf("no languages loaded from %s", opts.Path)
+	}
+	if len(opts.Overlays) > 0 {
+		overlays, err := LoadOverlays(ctx, opts.Overlays, opts.Load.Fetch)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load language overlays: %w", err)
+		}
+		if languages, err = languages.ApplyOverlays(overlays); err != nil {
+			return nil, nil, fmt.Errorf("failed to apply language overlays: %w", err)
+		}
+		// the run records upstream's hash, the overlays are only named
+		src.Path = fmt.Sprintf("%s + %s", src.Path, strings.Join(opts.Overlays, " + "))
+	}
+	return languages, src, nil
+}
+
+// IngestLanguages loads languages.yml with its overlays and syncs the languages table with the result
+func IngestLanguages(ctx context.Context, conn TxBeginner, opts IngestOptions) (*LanguageSyncSummary, error) {
+	languages, src, err := LoadLanguages(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := SyncLanguages(ctx, conn, languages.ToPgType(), src, SyncOptions{Overlays: opts.Overlays})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync languages table: %w", err)
+	}
+	return summary, nil
+}
+
+// IngestHeuristics loads heuristics.yml and replaces the heuristic tables with it
+func IngestHeuristics(ctx context.Context, conn TxBeginner, path string, fetch FetchOptions) (*HeuristicsSyncSummary, error) {
+	var heuristics HeuristicsNonPgtype
+	if _, err := heuristics.Load(ctx, path, fetch); err != nil {
+		return nil, fmt.Errorf("failed to load linguist heuristics: %w", err)
+	}
+	summary, err := SyncHeuristics(ctx, conn, heuristics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync heuristics tables: %w", err)
+	}
+	return summary, nil
+}
+
+// IngestPathRules loads the vendored, documentation and generated file rules and replaces the path rule tables with them
+func IngestPathRules(ctx context.Context, conn TxBeginner, opts PathRulesOptions) (*PathRulesSyncSummary, error) {
+	rules, err := LoadPathRules(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := SyncPathRules(ctx, conn, rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync path rules tables: %w", err)
+	}
+	return summary, nil
+}
+
+// LoadGrammars loads grammars.yml, its scopes are what LoadOptions.Scopes checks languages against
+func LoadGrammars(ctx context.Context, path string, fetch FetchOptions) (GrammarsNonPgtype, error) {
+	var grammars GrammarsNonPgtype
+	if _, err := grammars.Load(ctx, path, fetch); err != nil {
+		return nil, fmt.Errorf("failed to load linguist grammars: %w", err)
+	}
+	return grammars, nil
+}
+
+// IngestGrammars replaces the grammars table with grammars and logs every language whose scope is missing,
+// including the ones overlays added after languages.yml was checked against LoadOptions.Scopes
+func IngestGrammars(ctx context.Context, conn TxBeginner, grammars GrammarsNonPgtype) (*GrammarsSyncSummary, error) {
+	summary, err := SyncGrammars(ctx, conn, grammars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync grammars table: %w", err)
+	}
+	for _, lang := range summary.MissingScopes {
+		log.Warn().Str("language", lang.Name).Str("tm_scope", lang.TmScope.String).Msg("language scope is not provided by any grammar")
+	}
+	return summary, nil
+}
+
+// LinguistOptions names every source IngestLinguist reads, paths accept the forms of LanguagesNonPgtype.Load
+type LinguistOptions struct {
+	LanguagesPath string
+	// Overlays are organization specific languages.yml files merged over LanguagesPath, in order
+	Overlays []string
+	// Strict aborts the ingest when any languages.yml entry is invalid, see LoadOptions
+	Strict            bool
+	HeuristicsPath    string
+	VendorPath        string
+	DocumentationPath string
+	GeneratedPath     string
+	// GrammarsPath is grammars.yml, empty skips the grammars and the tm_scope check
+	GrammarsPath string
+	Fetch        FetchOptions
+}
+
+// DefaultLinguistOptions returns the upstream Linguist sources fetched with DefaultFetchOptions
+func DefaultLinguistOptions() LinguistOptions {
+	return LinguistOptions{
+		LanguagesPath:     DefaultLanguagesPath,
+		HeuristicsPath:    DefaultHeuristicsPath,
+		VendorPath:        DefaultVendorPath,
+		DocumentationPath: DefaultDocumentationPath,
+		GeneratedPath:     DefaultGeneratedPath,
+		GrammarsPath:      DefaultGrammarsPath,
+		Fetch:             DefaultFetchOptions(),
+	}
+}
+
+// LinguistSyncSummary holds what IngestLinguist wrote for each source
+type LinguistSyncSummary struct {
+	Languages  *LanguageSyncSummary
+	Heuristics *HeuristicsSyncSummary
+	PathRules  *PathRulesSyncSummary
+	Grammars   *GrammarsSyncSummary
+}
+
+// IngestLinguist ingests every Linguist source, as both seer migrate and the server resync do. Grammars are
+// loaded first so languages.yml entries are checked against their scopes, then the languages, heuristics,
+// path rules and grammars tables are synced in that order, each in its own transaction. Without a grammars source
+// the grammars table is left alone and Grammars in the summary is nil.
+// The caller is expected to hold LanguageSyncLockKey.
+func IngestLinguist(ctx context.Context, conn TxBeginner, opts LinguistOptions) (*LinguistSyncSummary, error) {
+	var grammars GrammarsNonPgtype
+	var scopes map[string]bool
+	if opts.GrammarsPath != "" {
+		var err error
+		if grammars, err = LoadGrammars(ctx, opts.GrammarsPath, opts.Fetch); err != nil {
+			return nil, err
+		}
+		scopes = grammars.Scopes()
+	}
+	var summary LinguistSyncSummary
+	var err error
+	summary.Languages, err = IngestLanguages(ctx, conn, IngestOptions{
+		Path:     opts.LanguagesPath,
+		Overlays: opts.Overlays,
+		Load: LoadOptions{
+			Strict: opts.Strict,
+			Fetch:  opts.Fetch,
+			Scopes: scopes,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest linguist languages: %w", err)
+	}
+	if summary.Heuristics, err = IngestHeuristics(ctx, conn, opts.HeuristicsPath, opts.Fetch); err != nil {
+		return nil, fmt.Errorf("failed to ingest linguist heuristics: %w", err)
+	}
+	summary.PathRules, err = IngestPathRules(ctx, conn, PathRulesOptions{
+		VendorPath:        opts.VendorPath,
+		DocumentationPath: opts.DocumentationPath,
+		GeneratedPath:     opts.GeneratedPath,
+		Fetch:             opts.Fetch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest path rules: %w", err)
+	}
+	if grammars == nil {
+		return &summary, nil
+	}
+	if summary.Grammars, err = IngestGrammars(ctx, conn, grammars); err != nil {
+		return nil, fmt.Errorf("failed to ingest linguist grammars: %w", err)
+	}
+	return &summary, nil
+}
@@ -1,10 +1,9 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"math"
-	"net/http"
+	"sort"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog"
@@ -15,83 +14,124 @@ import (
 // LanguageNonPgtype stores programming language definitions and metadata
 type LanguageNonPgtype struct {
 	// Primary name of the language
-	Name string `yaml:"name"`
+	Name string `yaml:"name,omitempty" json:"name"`
 	// Optional field. Only necessary as a replacement for the sample directory name if the language name is not a valid filename
-	FsName string `yaml:"fs_name"`
+	FsName string `yaml:"fs_name,omitempty" json:"fs_name,omitempty"`
 	// Category of the language: data, programming, markup, prose, or null
-	Type string `yaml:"type"`
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
 	// An array of additional aliases (implicitly includes name.downcase)
-	Aliases []string `yaml:"aliases"`
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
 	// A String name of the Ace Mode used for highlighting whenever a file is edited. This must match one of the filenames in https://gh.io/acemodes. Use "text" if a mode does not exist
-	AceMode string `yaml:"ace_mode"`
+	AceMode string `yaml:"ace_mode,omitempty" json:"ace_mode,omitempty"`
 	// A String name of the CodeMirror Mode used for highlighting whenever a file is edited. This must match a mode from https://git.io/vi9Fx
-	CodemirrorMode string `yaml:"codemirror_mode"`
+	CodemirrorMode string `yaml:"codemirror_mode,omitempty" json:"codemirror_mode,omitempty"`
 	// A String name of the file mime type used for highlighting whenever a file is edited. This should match the `mime` associated with the mode from https://git.io/f4SoQ
-	CodemirrorMimeType string `yaml:"codemirror_mime_type"`
+	CodemirrorMimeType string `yaml:"codemirror_mime_type,omitempty" json:"codemirror_mime_type,omitempty"`
 	// Boolean value to enable line wrapping (default: false)
-	Wrap bool `yaml:"wrap"`
+	Wrap bool `yaml:"wrap,omitempty" json:"wrap,omitempty"`
 	// An array of associated extensions (the first one is considered the primary extension, the others should be listed alphabetically)
-	Extensions []string `yaml:"extensions"`
+	Extensions []string `yaml:"extensions,omitempty" json:"extensions,omitempty"`
 	// An array of filenames commonly associated with the language
-	Filenames []string `yaml:"filenames"`
+	Filenames []string `yaml:"filenames,omitempty" json:"filenames,omitempty"`
 	// An array of associated interpreters
-	Interpreters []string `yaml:"interpreters"`
+	Interpreters []string `yaml:"interpreters,omitempty" json:"interpreters,omitempty"`
 	// Integer used as a language-name-independent indexed field so that we can rename languages in Linguist without reindexing all the code on GitHub
-	LanguageID int32 `yaml:"language_id"`
+	LanguageID int32 `yaml:"language_id" json:"language_id"`
 	// CSS hex color to represent the language. Only used if type is "programming" or "markup"
-	Color string `yaml:"color"`
+	Color string `yaml:"color,omitempty" json:"color,omitempty"`
 	// The TextMate scope that represents this programming language. This should match one of the scopes listed in the grammars.yml file. Use "none" if there is no grammar for this language
-	TmScope string `yaml:"tm_scope"`
+	TmScope string `yaml:"tm_scope,omitempty" json:"tm_scope,omitempty"`
 	// Name of the parent language. Languages in a group are counted in the statistics as the parent language
-	Group string `yaml:"group"`
+	Group string `yaml:"group,omitempty" json:"group,omitempty"`
+	// Set for languages added or modified by an overlay, never read from YAML
+	Custom bool `yaml:"-" json:"custom,omitempty"`
+	// Path of the overlay file that last added or modified the language, never read from YAML
+	Overlay string `yaml:"-" json:"overlay,omitempty"`
 }
 
 // LanguagesNonPgtype represents a collection of programming language definitions, without pgtypes.
 type LanguagesNonPgtype []LanguageNonPgtype
 
-// Load fetches programming language definitions from a remote YAML file specified by remote_path
+// LoadOptions tunes how LanguagesNonPgtype.Load treats invalid entries
+type LoadOptions struct {
+	// Strict aborts the whole load when any entry is invalid or has a warning, instead of skipping the invalid entries
+	Strict bool
+	// Fetch tunes the download when path is an http(s) URL
+	Fetch FetchOptions
+	// Scopes, when set, warns about an entry whose tm_scope none of them provide, see GrammarsNonPgtype.Scopes
+	Scopes map[string]bool
+}
+
+// Load reads programming language definitions from the YAML file at path, see fetchSource for the accepted forms.
 // https://raw.githubusercontent.com/github-linguist/linguist/refs/heads/main/lib/linguist/languages.yml
-// should be used
-func (l *LanguagesNonPgtype) Load(remote_path string) {
-	req, err := http.NewRequest(http.MethodGet, remote_path, nil)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to create request")
-		return
-	}
-	resp, err := http.DefaultClient.Do(req)
+// should be used for remote loads, SourceEmbedded serves the snapshot compiled into the binary
+//
+// Failing to fetch or parse the file returns an error and loads nothing. Entries that fail to decode or validate
+// are reported through a *LanguageLoadError, valid entries are still loaded unless opts.Strict is set.
+// Warnings are reported the same way, their entries are loaded too, and a strict load fails on them as well.
+// The returned Source describes what was fetched, and is set whenever languages were loaded.
+func (l *LanguagesNonPgtype) Load(ctx context.Context, path string, opts LoadOptions) (*Source, error) {
+	src, err := fetchSource(ctx, path, embeddedLanguages, opts.Fetch)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to get linguist languages")
-		return
+		return nil, fmt.Errorf("failed to fetch linguist languages: %w", err)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Error().Err(err).Msg("failed to close response body")
-		}
-	}()
-	resp_bytes, err := io.ReadAll(resp.Body)
+	keys, languageYaml, err := decodeLanguageNodes(src)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to read response body")
-		return
-	}
-	var languageYaml map[string]interface{}
-	if err := yaml.Unmarshal(resp_bytes, &languageYaml); err != nil {
-		log.Error().Err(err).Msg("failed to unmarshal language config into map")
-		return
+		return nil, err
 	}
-	for k, v := range languageYaml {
+
+	loadErr := &LanguageLoadError{Path: path}
+	loaded := make(LanguagesNonPgtype, 0, len(keys))
+	seenIDs := make(map[int32]string, len(keys))
+	for _, k := range keys {
+		node := languageYaml[k]
 		var language LanguageNonPgtype
-		mv, err := yaml.Marshal(v)
-		if err != nil {
-			log.Error().Str("key", k).Err(err).Msg("failed to marshal language config")
-			return
-		}
-		if err := yaml.Unmarshal(mv, &language); err != nil {
-			log.Error().Str("key", k).Str("value", string(mv)).Err(err).Msg("failed to unmarshal language config back to struct")
-			return
+		if err := node.Decode(&language); err != nil {
+			loadErr.add(k, fmt.Errorf("line %d: %w", node.Line, err))
+			continue
 		}
 		language.Name = k
-		*l = append(*l, language)
+		problems := language.Validate()
+		if other, ok := seenIDs[language.LanguageID]; ok {
+			problems = append(problems, fmt.Errorf("language_id %d is already used by %s", language.LanguageID, other))
+		}
+		if len(problems) > 0 {
+			loadErr.add(k, problems...)
+			continue
+		}
+		if opts.Scopes != nil {
+			if err := language.validateScope(opts.Scopes); err != nil {
+				loadErr.warn(k, err)
+			}
+		}
+		seenIDs[language.LanguageID] = k
+		loaded = append(loaded, language)
 	}
+	if !loadErr.empty() && opts.Strict {
+		return nil, loadErr
+	}
+	*l = append(*l, loaded...)
+	if !loadErr.empty() {
+		return src, loadErr
+	}
+	return src, nil
+}
+
+// decodeLanguageNodes splits a languages.yml shaped file into its entries, returning the keys in sorted order
+func decodeLanguageNodes(src *Source) ([]string, map[string]yaml.Node, error) {
+	var languageYaml map[string]yaml.Node
+	if err := yaml.Unmarshal(src.Data, &languageYaml); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal %s: %w", src.Path, err)
+	}
+	if len(languageYaml) == 0 {
+		return nil, nil, fmt.Errorf("no languages found in %s", src.Path)
+	}
+	keys := make([]string, 0, len(languageYaml))
+	for k := range languageYaml {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, languageYaml, nil
 }
 
 // ToPgType converts a slice of LanguageNonPgtype to a slice of Language with PostgreSQL-compatible types
@@ -103,27 +143,20 @@ func (l LanguagesNonPgtype) ToPgType() []Language {
 			Aliases:      lang.Aliases,
 			Extensions:   lang.Extensions,
 			Filenames:    lang.Filenames,
-			Interpreters: lang.Filenames,
+			Interpreters: lang.Interpreters,
 			LanguageID:   lang.LanguageID,
 			Wrap:         pgtype.Bool{Bool: lang.Wrap, Valid: true},
+			Custom:       lang.Custom,
 		}
 		if lang.FsName != "" {
 			dbLanguage.FsName = pgtype.Text{String: lang.FsName, Valid: true}
 		} else {
 			dbLanguage.FsName = pgtype.Text{String: "", Valid: true}
 		}
-		if lang.Type != "" {
-			var ltype LanguageType
-			switch lang.Type {
-			case "data":
-				ltype = LanguageTypeData
-			case "programming":
-				ltype = LanguageTypeData
-			case "markup":
-				ltype = LanguageTypeMarkup
-			case "prose":
-				ltype = LanguageTypeProse
-			}
+		if lang.Overlay != "" {
+			dbLanguage.Overlay = pgtype.Text{String: lang.Overlay, Valid: true}
+		}
+		if ltype, ok := parseLanguageType(lang.Type); ok {
 			dbLanguage.Type = NullLanguageType{LanguageType: ltype, Valid: true}
 		} else {
 			dbLanguage.Type = NullLanguageType{LanguageType: LanguageTypeData, Valid: false}
@@ -163,9 +196,9 @@ func (l LanguagesNonPgtype) ToPgType() []Language {
 	return dbLanguages
 }
 
+// LanguageColumns returns the languages columns written by LanguageCopyFrom, id is left to the serial sequence
 func LanguageColumns() []string {
 	return []string{
-		"id",
 		"name",
 		"fs_name",
 		"type",
@@ -181,6 +214,9 @@ func LanguageColumns() []string {
 		"color",
 		"tm_scope",
 		"group",
+		"ingest_run_id",
+		"custom",
+		"overlay",
 	}
 }
 
@@ -216,22 +252,17 @@ type LanguageCopyFrom struct {
 func (l *LanguageCopyFrom) Next() bool {
 	l.i++
 	log.Trace().Int("i", l.i).Msg("incrementing LanguageCopyFrom index")
-	return l.i < len(l.Languages)
+	return l.i <= len(l.Languages)
 }
 
 // Values returns the values for the current row.
 func (l *LanguageCopyFrom) Values() ([]any, error) {
-	lang := l.Languages[l.i]
-	log.Trace().Int("i", l.i).Object("language", lang).Msg("yielding from LanguageCopyFrom.Values")
-	if l.i >= len(l.Languages) {
-		return nil, nil
-	}
-	if l.i > math.MaxInt32 {
-		return nil, fmt.Errorf("index %d exceeds maximum value for int32", l.i)
+	if l.i < 1 || l.i > len(l.Languages) {
+		return nil, fmt.Errorf("LanguageCopyFrom index %d is out of range", l.i)
 	}
-	lang.ID = int32(l.i - 1) //nolint: gosec
+	lang := l.Languages[l.i-1]
+	log.Trace().Int("i", l.i).Object("language", lang).Msg("yielding from LanguageCopyFrom.Values")
 	return []any{
-		lang.ID,
 		lang.Name,
 		lang.FsName,
 		lang.Type,
@@ -247,6 +278,9 @@ func (l *LanguageCopyFrom) Values() ([]any, error) {
 		lang.Color,
 		lang.TmScope,
 		lang.Group,
+		lang.IngestRunID,
+		lang.Custom,
+		lang.Overlay,
 	}, nil
 }
 
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LanguageSyncLockKey is the Postgres advisory lock key held while the languages table is being synced,
+// migrate holds it across the schema migration as well
+const LanguageSyncLockKey int64 = 0x73656572_00000001 // "seer" in the high bits
+
+// TryWithAdvisoryLock runs fn while holding the session level advisory lock key on conn.
+// It returns false without running fn when another session already holds the lock.
+// The lock belongs to the connection, so conn must not be handed back to a pool while fn runs.
+func TryWithAdvisoryLock(ctx context.Context, conn DBTX, key int64, fn func() error) (bool, error) {
+	q := New(conn)
+	locked, err := q.TryAdvisoryLock(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to try advisory lock: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		// ctx may already be done, the unlock must still reach the server
+		if _, err := q.AdvisoryUnlock(context.WithoutCancel(ctx), key); err != nil {
+			log.Error().Err(err).Int64("key", key).Msg("failed to release advisory lock")
+		}
+	}()
+	return true, fn()
+}
+
+// WithAdvisoryLock runs fn while holding the session level advisory lock key on conn, waiting for
+// other sessions to release it first. The wait ends early when ctx is cancelled.
+// The lock belongs to the connection, so conn must not be handed back to a pool while fn runs.
+func WithAdvisoryLock(ctx context.Context, conn DBTX, key int64, fn func() error) error {
+	locked, err := TryWithAdvisoryLock(ctx, conn, key, fn)
+	if err != nil || locked {
+		return err
+	}
+	log.Info().Int64("key", key).Msg("waiting for another session to release the advisory lock")
+	q := New(conn)
+	if err := q.AdvisoryLock(ctx, key); err != nil {
+		return fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	defer func() {
+		if _, err := q.AdvisoryUnlock(context.WithoutCancel(ctx), key); err != nil {
+			log.Error().Err(err).Int64("key", key).Msg("failed to release advisory lock")
+		}
+	}()
+	return fn()
+}
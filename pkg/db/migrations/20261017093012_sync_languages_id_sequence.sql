@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- rows seeded by the old CopyFrom path carried explicit ids and never advanced the sequence,
+-- so new rows inserted during a sync would collide with them
+SELECT setval(pg_get_serial_sequence('languages', 'id'), COALESCE(MAX(id), 0) + 1, false)
+FROM languages;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+SELECT 1;
+-- +goose StatementEnd
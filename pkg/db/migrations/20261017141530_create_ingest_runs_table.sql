@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.ingest_runs (
+  id SERIAL PRIMARY KEY,
+  source TEXT NOT NULL,
+  sha256 CHAR(64),
+  etag TEXT,
+  added_count INTEGER NOT NULL DEFAULT 0,
+  changed_count INTEGER NOT NULL DEFAULT 0,
+  removed_count INTEGER NOT NULL DEFAULT 0,
+  total_count INTEGER NOT NULL DEFAULT 0,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at TIMESTAMPTZ
+);
+COMMENT ON TABLE ingest_runs IS 'Records every sync of the languages table and where its data came from';
+COMMENT ON COLUMN ingest_runs.source IS 'Path or URL the languages were loaded from';
+COMMENT ON COLUMN ingest_runs.sha256 IS 'Hex encoded sha256 of the fetched languages.yml';
+COMMENT ON COLUMN ingest_runs.etag IS 'ETag returned by the server, only set for http sources';
+COMMENT ON COLUMN ingest_runs.total_count IS 'Number of languages in the table after the run';
+COMMENT ON COLUMN ingest_runs.finished_at IS 'Null while the run is in progress or if it never committed';
+ALTER TABLE public.languages
+ADD COLUMN ingest_run_id INTEGER REFERENCES ingest_runs (id);
+COMMENT ON COLUMN languages.ingest_run_id IS 'Ingest run that last inserted or updated the language';
+CREATE TABLE public.language_snapshots (
+  ingest_run_id INTEGER NOT NULL REFERENCES ingest_runs (id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  fs_name VARCHAR(255),
+  "type" language_type,
+  aliases TEXT [],
+  ace_mode VARCHAR(100),
+  codemirror_mode VARCHAR(100),
+  codemirror_mime_type VARCHAR(100),
+  wrap BOOLEAN DEFAULT false,
+  extensions TEXT [],
+  filenames TEXT [],
+  interpreters TEXT [],
+  language_id INTEGER NOT NULL,
+  color CHAR(7),
+  tm_scope VARCHAR(255),
+  "group" VARCHAR(255),
+  PRIMARY KEY (ingest_run_id, language_id)
+);
+COMMENT ON TABLE language_snapshots IS 'Copy of the languages table as it was left by each ingest run, used for rollbacks';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.language_snapshots;
+ALTER TABLE public.languages DROP COLUMN IF EXISTS ingest_run_id;
+DROP TABLE IF EXISTS public.ingest_runs;
+-- +goose StatementEnd
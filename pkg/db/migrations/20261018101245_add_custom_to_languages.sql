@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.languages
+ADD COLUMN custom BOOLEAN NOT NULL DEFAULT false;
+COMMENT ON COLUMN languages.custom IS 'Set when the language was added or modified by an organization overlay. Custom languages are never dropped by an upstream sync';
+ALTER TABLE public.language_snapshots
+ADD COLUMN custom BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.language_snapshots DROP COLUMN IF EXISTS custom;
+ALTER TABLE public.languages DROP COLUMN IF EXISTS custom;
+-- +goose StatementEnd
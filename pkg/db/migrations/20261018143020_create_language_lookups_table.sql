@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE language_lookup_kind AS ENUM ('extension', 'filename', 'interpreter', 'alias');
+CREATE TABLE public.language_lookups (
+  language_id INTEGER NOT NULL REFERENCES languages (language_id) ON DELETE CASCADE,
+  kind language_lookup_kind NOT NULL,
+  value TEXT NOT NULL,
+  position INTEGER NOT NULL,
+  PRIMARY KEY (language_id, kind, value)
+);
+CREATE INDEX language_lookups_kind_value_idx ON language_lookups (kind, value);
+CREATE INDEX language_lookups_kind_lower_value_idx ON language_lookups (kind, lower(value));
+COMMENT ON TABLE language_lookups IS 'One row per extension, filename, interpreter and alias of a language, rebuilt from the languages arrays on every ingest run';
+COMMENT ON COLUMN language_lookups.value IS 'Value as written in languages.yml, aliases are lower case and include the lower case language name';
+COMMENT ON COLUMN language_lookups.position IS 'One based position in the source array, the primary extension has position 1 and the implicit name alias 0';
+INSERT INTO language_lookups (language_id, kind, value, position)
+SELECT l.language_id, 'extension'::language_lookup_kind, e.value, e.position
+FROM languages l,
+  unnest(l.extensions) WITH ORDINALITY AS e(value, position)
+UNION ALL
+SELECT l.language_id, 'filename'::language_lookup_kind, f.value, f.position
+FROM languages l,
+  unnest(l.filenames) WITH ORDINALITY AS f(value, position)
+UNION ALL
+SELECT l.language_id, 'interpreter'::language_lookup_kind, i.value, i.position
+FROM languages l,
+  unnest(l.interpreters) WITH ORDINALITY AS i(value, position)
+UNION ALL
+SELECT l.language_id, 'alias'::language_lookup_kind, lower(l.name), 0
+FROM languages l
+UNION ALL
+SELECT l.language_id, 'alias'::language_lookup_kind, lower(a.value), a.position
+FROM languages l,
+  unnest(l.aliases) WITH ORDINALITY AS a(value, position)
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.language_lookups;
+DROP TYPE IF EXISTS language_lookup_kind;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.languages
+ADD COLUMN parent_id INTEGER REFERENCES languages (language_id) ON DELETE SET NULL;
+CREATE INDEX languages_parent_id_idx ON languages (parent_id);
+COMMENT ON COLUMN languages.parent_id IS 'language_id of the language named by group, resolved on every ingest run';
+UPDATE languages c
+SET parent_id = (
+    SELECT p.language_id
+    FROM languages p
+    WHERE p.name = c."group"
+    LIMIT 1
+  )
+WHERE c."group" IS NOT NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.languages DROP COLUMN IF EXISTS parent_id;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.heuristic_disambiguations (
+  id SERIAL PRIMARY KEY,
+  position INTEGER NOT NULL,
+  extensions TEXT [] NOT NULL
+);
+COMMENT ON TABLE heuristic_disambiguations IS 'Linguist heuristics.yml disambiguations, each settles the language of files sharing one of its extensions';
+COMMENT ON COLUMN heuristic_disambiguations.position IS 'Zero based position in heuristics.yml';
+CREATE INDEX heuristic_disambiguations_extensions_idx ON heuristic_disambiguations USING GIN (extensions);
+CREATE TABLE public.heuristic_rules (
+  id SERIAL PRIMARY KEY,
+  disambiguation_id INTEGER NOT NULL REFERENCES heuristic_disambiguations (id) ON DELETE CASCADE,
+  parent_rule_id INTEGER REFERENCES heuristic_rules (id) ON DELETE CASCADE,
+  position INTEGER NOT NULL,
+  languages TEXT [],
+  pattern TEXT [],
+  negative_pattern TEXT [],
+  named_pattern VARCHAR(255)
+);
+COMMENT ON TABLE heuristic_rules IS 'Rules of a disambiguation, tried in position order. The first matching top level rule decides the language';
+COMMENT ON COLUMN heuristic_rules.parent_rule_id IS 'Set for the members of an and rule, which all have to match';
+COMMENT ON COLUMN heuristic_rules.position IS 'Zero based position within the disambiguation, or within the parent and rule';
+COMMENT ON COLUMN heuristic_rules.languages IS 'Languages picked when the rule matches, null for and members';
+COMMENT ON COLUMN heuristic_rules.pattern IS 'Ruby regular expressions, any of which has to match';
+COMMENT ON COLUMN heuristic_rules.negative_pattern IS 'Ruby regular expressions, none of which may match';
+COMMENT ON COLUMN heuristic_rules.named_pattern IS 'Name of a heuristic_named_patterns entry that has to match';
+CREATE INDEX heuristic_rules_disambiguation_id_idx ON heuristic_rules (disambiguation_id);
+CREATE TABLE public.heuristic_named_patterns (
+  name VARCHAR(255) PRIMARY KEY,
+  pattern TEXT [] NOT NULL
+);
+COMMENT ON TABLE heuristic_named_patterns IS 'Patterns shared between heuristic rules through named_pattern';
+COMMENT ON COLUMN heuristic_named_patterns.pattern IS 'Ruby regular expressions, any of which has to match';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.heuristic_named_patterns;
+DROP TABLE IF EXISTS public.heuristic_rules;
+DROP TABLE IF EXISTS public.heuristic_disambiguations;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE path_rule_kind AS ENUM ('vendor', 'documentation', 'generated');
+CREATE TABLE public.path_rules (
+  id SERIAL PRIMARY KEY,
+  kind path_rule_kind NOT NULL,
+  position INTEGER NOT NULL,
+  pattern TEXT NOT NULL,
+  UNIQUE (kind, position)
+);
+COMMENT ON TABLE path_rules IS 'Path patterns from Linguist vendor.yml, documentation.yml and the generated file rules. Matching files are left out of language statistics';
+COMMENT ON COLUMN path_rules.position IS 'Zero based position in the source list';
+COMMENT ON COLUMN path_rules.pattern IS 'Ruby regular expression matched against the slash separated path relative to the repository root';
+CREATE TABLE public.generated_extensions (
+  extension TEXT PRIMARY KEY
+);
+COMMENT ON TABLE generated_extensions IS 'Extensions of files that are always generated, such as .nib';
+CREATE TABLE public.generated_content_rules (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  position INTEGER NOT NULL UNIQUE,
+  extensions TEXT [] NOT NULL,
+  pattern TEXT,
+  lines INTEGER NOT NULL DEFAULT 0,
+  min_average_line_length INTEGER
+);
+COMMENT ON TABLE generated_content_rules IS 'Content checks marking a file as generated, only tried for files with one of the rule extensions';
+COMMENT ON COLUMN generated_content_rules.pattern IS 'Regular expression matched against each searched line';
+COMMENT ON COLUMN generated_content_rules.lines IS 'Number of leading lines searched, negative counts trailing lines, zero searches the whole file';
+COMMENT ON COLUMN generated_content_rules.min_average_line_length IS 'Files whose lines average more characters than this are generated, used for minified code';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.generated_content_rules;
+DROP TABLE IF EXISTS public.generated_extensions;
+DROP TABLE IF EXISTS public.path_rules;
+DROP TYPE IF EXISTS path_rule_kind;
+-- +goose StatementEnd
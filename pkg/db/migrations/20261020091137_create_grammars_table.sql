@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.grammars (
+  scope VARCHAR(255) PRIMARY KEY,
+  repository TEXT NOT NULL
+);
+COMMENT ON TABLE grammars IS 'TextMate scopes from Linguist grammars.yml and the grammar repository providing each';
+COMMENT ON COLUMN grammars.repository IS 'Submodule path such as vendor/grammars/language-go, or the URL of a grammar archive';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.grammars;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.language_syntax (
+  language_id INTEGER PRIMARY KEY REFERENCES languages (language_id) ON DELETE CASCADE,
+  catalog VARCHAR(32) NOT NULL,
+  catalog_name VARCHAR(255) NOT NULL,
+  line_comments TEXT [] NOT NULL DEFAULT '{}',
+  block_comment_starts TEXT [] NOT NULL DEFAULT '{}',
+  block_comment_ends TEXT [] NOT NULL DEFAULT '{}',
+  nested_block_comments BOOLEAN NOT NULL DEFAULT false,
+  quote_starts TEXT [] NOT NULL DEFAULT '{}',
+  quote_ends TEXT [] NOT NULL DEFAULT '{}',
+  imported_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+COMMENT ON TABLE language_syntax IS 'Comment and string syntax of a language imported from an alternative catalog such as tokei or scc, used for line counting';
+COMMENT ON COLUMN language_syntax.catalog IS 'Catalog the syntax was imported from, tokei or scc';
+COMMENT ON COLUMN language_syntax.catalog_name IS 'Name of the language in the catalog';
+COMMENT ON COLUMN language_syntax.line_comments IS 'Tokens starting a comment that runs to the end of the line';
+COMMENT ON COLUMN language_syntax.block_comment_starts IS 'Tokens opening a block comment, block_comment_ends holds the closing token at the same index';
+COMMENT ON COLUMN language_syntax.nested_block_comments IS 'Whether block comments nest';
+COMMENT ON COLUMN language_syntax.quote_starts IS 'Tokens opening a string literal, quote_ends holds the closing token at the same index';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.language_syntax;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.languages
+ADD COLUMN overlay TEXT;
+COMMENT ON COLUMN languages.overlay IS 'Path of the overlay file that last added or modified the language, null for upstream languages and custom languages synced before overlays were tracked';
+ALTER TABLE public.language_snapshots
+ADD COLUMN overlay TEXT;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.language_snapshots DROP COLUMN IF EXISTS overlay;
+ALTER TABLE public.languages DROP COLUMN IF EXISTS overlay;
+-- +goose StatementEnd
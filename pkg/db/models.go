@@ -11,6 +11,50 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type LanguageLookupKind string
+
+const (
+	LanguageLookupKindExtension   LanguageLookupKind = "extension"
+	LanguageLookupKindFilename    LanguageLookupKind = "filename"
+	LanguageLookupKindInterpreter LanguageLookupKind = "interpreter"
+	LanguageLookupKindAlias       LanguageLookupKind = "alias"
+)
+
+func (e *LanguageLookupKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LanguageLookupKind(s)
+	case string:
+		*e = LanguageLookupKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LanguageLookupKind: %T", src)
+	}
+	return nil
+}
+
+type NullLanguageLookupKind struct {
+	LanguageLookupKind LanguageLookupKind
+	Valid              bool // Valid is true if LanguageLookupKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLanguageLookupKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.LanguageLookupKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LanguageLookupKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLanguageLookupKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LanguageLookupKind), nil
+}
+
 type LanguageType string
 
 const (
@@ -55,6 +99,127 @@ func (ns NullLanguageType) Value() (driver.Value, error) {
 	return string(ns.LanguageType), nil
 }
 
+type PathRuleKind string
+
+const (
+	PathRuleKindVendor        PathRuleKind = "vendor"
+	PathRuleKindDocumentation PathRuleKind = "documentation"
+	PathRuleKindGenerated     PathRuleKind = "generated"
+)
+
+func (e *PathRuleKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PathRuleKind(s)
+	case string:
+		*e = PathRuleKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PathRuleKind: %T", src)
+	}
+	return nil
+}
+
+type NullPathRuleKind struct {
+	PathRuleKind PathRuleKind
+	Valid        bool // Valid is true if PathRuleKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPathRuleKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.PathRuleKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PathRuleKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPathRuleKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PathRuleKind), nil
+}
+
+// Content checks marking a file as generated, only tried for files with one of the rule extensions
+type GeneratedContentRule struct {
+	ID         int32
+	Name       string
+	Position   int32
+	Extensions []string
+	// Regular expression matched against each searched line
+	Pattern pgtype.Text
+	// Number of leading lines searched, negative counts trailing lines, zero searches the whole file
+	Lines int32
+	// Files whose lines average more characters than this are generated, used for minified code
+	MinAverageLineLength pgtype.Int4
+}
+
+// Extensions of files that are always generated, such as .nib
+type GeneratedExtension struct {
+	Extension string
+}
+
+// TextMate scopes from Linguist grammars.yml and the grammar repository providing each
+type Grammar struct {
+	Scope string
+	// Submodule path such as vendor/grammars/language-go, or the URL of a grammar archive
+	Repository string
+}
+
+// Linguist heuristics.yml disambiguations, each settles the language of files sharing one of its extensions
+type HeuristicDisambiguation struct {
+	ID int32
+	// Zero based position in heuristics.yml
+	Position   int32
+	Extensions []string
+}
+
+// Patterns shared between heuristic rules through named_pattern
+type HeuristicNamedPattern struct {
+	Name string
+	// Ruby regular expressions, any of which has to match
+	Pattern []string
+}
+
+// Rules of a disambiguation, tried in position order. The first matching top level rule decides the language
+type HeuristicRule struct {
+	ID               int32
+	DisambiguationID int32
+	// Set for the members of an and rule, which all have to match
+	ParentRuleID pgtype.Int4
+	// Zero based position within the disambiguation, or within the parent and rule
+	Position int32
+	// Languages picked when the rule matches, null for and members
+	Languages []string
+	// Ruby regular expressions, any of which has to match
+	Pattern []string
+	// Ruby regular expressions, none of which may match
+	NegativePattern []string
+	// Name of a heuristic_named_patterns entry that has to match
+	NamedPattern pgtype.Text
+}
+
+// Records every sync of the languages table and where its data came from
+type IngestRun struct {
+	ID int32
+	// Path or URL the languages were loaded from
+	Source string
+	// Hex encoded sha256 of the fetched languages.yml
+	Sha256 pgtype.Text
+	// ETag returned by the server, only set for http sources
+	Etag         pgtype.Text
+	AddedCount   int32
+	ChangedCount int32
+	RemovedCount int32
+	// Number of languages in the table after the run
+	TotalCount int32
+	StartedAt  pgtype.Timestamptz
+	// Null while the run is in progress or if it never committed
+	FinishedAt pgtype.Timestamptz
+}
+
 // Stores programming language definitions and metadata
 type Language struct {
 	ID int32
@@ -88,4 +253,74 @@ type Language struct {
 	TmScope pgtype.Text
 	// Name of the parent language. Languages in a group are counted in the statistics as the parent language
 	Group pgtype.Text
+	// Ingest run that last inserted or updated the language
+	IngestRunID pgtype.Int4
+	// Set when the language was added or modified by an organization overlay. Custom languages are never dropped by an upstream sync
+	Custom bool
+	// language_id of the language named by group, resolved on every ingest run
+	ParentID pgtype.Int4
+	// Path of the overlay file that last added or modified the language, null for upstream languages and custom languages synced before overlays were tracked
+	Overlay pgtype.Text
+}
+
+// One row per extension, filename, interpreter and alias of a language, rebuilt from the languages arrays on every ingest run
+type LanguageLookup struct {
+	LanguageID int32
+	Kind       LanguageLookupKind
+	// Value as written in languages.yml, aliases are lower case and include the lower case language name
+	Value string
+	// One based position in the source array, the primary extension has position 1 and the implicit name alias 0
+	Position int32
+}
+
+// Copy of the languages table as it was left by each ingest run, used for rollbacks
+type LanguageSnapshot struct {
+	IngestRunID        int32
+	Name               string
+	FsName             pgtype.Text
+	Type               NullLanguageType
+	Aliases            []string
+	AceMode            pgtype.Text
+	CodemirrorMode     pgtype.Text
+	CodemirrorMimeType pgtype.Text
+	Wrap               pgtype.Bool
+	Extensions         []string
+	Filenames          []string
+	Interpreters       []string
+	LanguageID         int32
+	Color              pgtype.Text
+	TmScope            pgtype.Text
+	Group              pgtype.Text
+	Custom             bool
+	Overlay            pgtype.Text
+}
+
+// Comment and string syntax of a language imported from an alternative catalog such as tokei or scc, used for line counting
+type LanguageSyntax struct {
+	LanguageID int32
+	// Catalog the syntax was imported from, tokei or scc
+	Catalog string
+	// Name of the language in the catalog
+	CatalogName string
+	// Tokens starting a comment that runs to the end of the line
+	LineComments []string
+	// Tokens opening a block comment, block_comment_ends holds the closing token at the same index
+	BlockCommentStarts []string
+	BlockCommentEnds   []string
+	// Whether block comments nest
+	NestedBlockComments bool
+	// Tokens opening a string literal, quote_ends holds the closing token at the same index
+	QuoteStarts []string
+	QuoteEnds   []string
+	ImportedAt  pgtype.Timestamptz
+}
+
+// Path patterns from Linguist vendor.yml, documentation.yml and the generated file rules. Matching files are left out of language statistics
+type PathRule struct {
+	ID   int32
+	Kind PathRuleKind
+	// Zero based position in the source list
+	Position int32
+	// Ruby regular expression matched against the slash separated path relative to the repository root
+	Pattern string
 }
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LanguageOverlay is an organization specific entry in the languages.yml schema, merged over the upstream data.
+// An overlay naming an upstream language extends its lists and overrides the scalar fields it sets,
+// any other overlay adds a new language and has to carry a language_id.
+type LanguageOverlay struct {
+	LanguageNonPgtype
+	// Path of the overlay file the entry came from
+	Path string
+	// hasLanguageID tells an explicit language_id of 0 apart from a missing one
+	hasLanguageID bool
+}
+
+// LanguageOverlays are applied in order, later entries win over earlier ones
+type LanguageOverlays []LanguageOverlay
+
+// LoadOverlays reads overlay files in order, paths accept the same forms as LanguagesNonPgtype.Load except SourceEmbedded.
+// Any invalid entry fails the whole load, since a half applied overlay is worse than none.
+func LoadOverlays(ctx context.Context, paths []string, fetch FetchOptions) (LanguageOverlays, error) {
+	var overlays LanguageOverlays
+	for _, path := range paths {
+		src, err := fetchSource(ctx, path, nil, fetch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch language overlay: %w", err)
+		}
+		keys, nodes, err := decodeLanguageNodes(src)
+		if err != nil {
+			return nil, err
+		}
+		loadErr := &LanguageLoadError{Path: path}
+		for _, k := range keys {
+			node := nodes[k]
+			overlay := LanguageOverlay{Path: path, hasLanguageID: mappingHasKey(&node, "language_id")}
+			if err := node.Decode(&overlay.LanguageNonPgtype); err != nil {
+				loadErr.add(k, fmt.Errorf("line %d: %w", node.Line, err))
+				continue
+			}
+			overlay.Name = k
+			if problems := overlay.Validate(); len(problems) > 0 {
+				loadErr.add(k, problems...)
+				continue
+			}
+			overlays = append(overlays, overlay)
+		}
+		if len(loadErr.Failures) > 0 {
+			return nil, loadErr
+		}
+	}
+	return overlays, nil
+}
+
+// mappingHasKey reports whether a YAML mapping node sets key
+func mappingHasKey(node *yaml.Node, key string) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyOverlays merges overlays over l, returning a new set where every added or modified language is marked custom
+// and records the overlay file that last touched it
+func (l LanguagesNonPgtype) ApplyOverlays(overlays LanguageOverlays) (LanguagesNonPgtype, error) {
+	merged := slices.Clone(l)
+	byName := make(map[string]int, len(merged))
+	byID := make(map[int32]string, len(merged))
+	for i, lang := range merged {
+		byName[lang.Name] = i
+		byID[lang.LanguageID] = lang.Name
+	}
+	for _, overlay := range overlays {
+		i, ok := byName[overlay.Name]
+		if !ok {
+			if !overlay.hasLanguageID {
+				return nil, fmt.Errorf("overlay %s in %s adds a new language and needs a language_id", overlay.Name, overlay.Path)
+			}
+			if other, taken := byID[overlay.LanguageID]; taken {
+				return nil, fmt.Errorf("overlay %s in %s uses language_id %d of %s", overlay.Name, overlay.Path, overlay.LanguageID, other)
+			}
+			lang := overlay.LanguageNonPgtype
+			lang.Custom = true
+			lang.Overlay = overlay.Path
+			byName[lang.Name] = len(merged)
+			byID[lang.LanguageID] = lang.Name
+			merged = append(merged, lang)
+			continue
+		}
+		lang := &merged[i]
+		if overlay.hasLanguageID && overlay.LanguageID != lang.LanguageID {
+			return nil, fmt.Errorf("overlay %s in %s cannot change language_id %d, language ids are stable", overlay.Name, overlay.Path, lang.LanguageID)
+		}
+		lang.mergeOverlay(overlay.LanguageNonPgtype)
+		lang.Custom = true
+		lang.Overlay = overlay.Path
+	}
+	return merged, nil
+}
+
+// mergeOverlay extends the list fields of l with the new entries of o, and overrides the scalar fields o sets
+func (l *LanguageNonPgtype) mergeOverlay(o LanguageNonPgtype) {
+	override := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	extend := func(dst *[]string, src []string) {
+		// the upstream slice may be shared with the set ApplyOverlays was called on
+		*dst = slices.Clip(*dst)
+		for _, v := range src {
+			if !slices.Contains(*dst, v) {
+				*dst = append(*dst, v)
+			}
+		}
+	}
+	override(&l.FsName, o.FsName)
+	override(&l.Type, o.Type)
+	override(&l.AceMode, o.AceMode)
+	override(&l.CodemirrorMode, o.CodemirrorMode)
+	override(&l.CodemirrorMimeType, o.CodemirrorMimeType)
+	override(&l.Color, o.Color)
+	override(&l.TmScope, o.TmScope)
+	override(&l.Group, o.Group)
+	if o.Wrap {
+		l.Wrap = true
+	}
+	extend(&l.Aliases, o.Aliases)
+	extend(&l.Extensions, o.Extensions)
+	extend(&l.Filenames, o.Filenames)
+	extend(&l.Interpreters, o.Interpreters)
+}
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultVendorPath is the upstream Linguist vendor.yml
+	DefaultVendorPath = "https://raw.githubusercontent.com/github/linguist/master/lib/linguist/vendor.yml"
+	// DefaultDocumentationPath is the upstream Linguist documentation.yml
+	DefaultDocumentationPath = "https://raw.githubusercontent.com/github/linguist/master/lib/linguist/documentation.yml"
+	// DefaultGeneratedPath serves the generated file rules maintained in this repository, Linguist keeps them in Ruby
+	DefaultGeneratedPath = SourceEmbedded
+)
+
+var (
+	//go:embed linguist/vendor.yml
+	embeddedVendor []byte
+	//go:embed linguist/documentation.yml
+	embeddedDocumentation []byte
+	//go:embed linguist/generated.yml
+	embeddedGenerated []byte
+)
+
+// GeneratedContentRuleNonPgtype marks a file as generated from its content, see linguist/generated.yml for the semantics
+type GeneratedContentRuleNonPgtype struct {
+	Name                 string   `yaml:"name"`
+	Extensions           []string `yaml:"extensions"`
+	Pattern              string   `yaml:"pattern"`
+	Lines                int32    `yaml:"lines"`
+	MinAverageLineLength int32    `yaml:"min_average_line_length"`
+}
+
+// GeneratedRulesNonPgtype is the content of linguist/generated.yml
+type GeneratedRulesNonPgtype struct {
+	Paths      []string                        `yaml:"paths"`
+	Extensions []string                        `yaml:"extensions"`
+	Content    []GeneratedContentRuleNonPgtype `yaml:"content"`
+}
+
+// PathRulesNonPgtype holds the vendored, documentation and generated file rules, without pgtypes
+type PathRulesNonPgtype struct {
+	Vendor        []string
+	Documentation []string
+	Generated     GeneratedRulesNonPgtype
+}
+
+// PathRulesOptions configures LoadPathRules, paths accept the same forms as LanguagesNonPgtype.Load
+type PathRulesOptions struct {
+	VendorPath        string
+	DocumentationPath string
+	GeneratedPath     string
+	Fetch             FetchOptions
+}
+
+// PathRulesSyncSummary counts the rows written by SyncPathRules
+type PathRulesSyncSummary struct {
+	Vendor              int
+	Documentation       int
+	Generated           int
+	GeneratedExtensions int
+	GeneratedContent    int
+}
+
+// LoadPathRules reads vendor.yml, documentation.yml and the generated file rules
+func LoadPathRules(ctx context.Context, opts PathRulesOptions) (*PathRulesNonPgtype, error) {
+	rules := &PathRulesNonPgtype{}
+	var err error
+	if rules.Vendor, err = loadPatternList(ctx, opts.VendorPath, embeddedVendor, opts.Fetch); err != nil {
+		return nil, fmt.Errorf("failed to load vendor rules: %w", err)
+	}
+	if rules.Documentation, err = loadPatternList(ctx, opts.DocumentationPath, embeddedDocumentation, opts.Fetch); err != nil {
+		return nil, fmt.Errorf("failed to load documentation rules: %w", err)
+	}
+	src, err := fetchSource(ctx, opts.GeneratedPath, embeddedGenerated, opts.Fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch generated rules: %w", err)
+	}
+	if err := yaml.Unmarshal(src.Data, &rules.Generated); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", src.Path, err)
+	}
+	if err := rules.Generated.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid generated rules in %s: %w", src.Path, err)
+	}
+	return rules, nil
+}
+
+// loadPatternList reads a YAML list of regular expressions such as vendor.yml
+func loadPatternList(ctx context.Context, path string, embedded []byte, fetch FetchOptions) ([]string, error) {
+	src, err := fetchSource(ctx, path, embedded, fetch)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	if err := yaml.Unmarshal(src.Data, &patterns); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", src.Path, err)
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no patterns found in %s", src.Path)
+	}
+	for i, p := range patterns {
+		if p == "" {
+			return nil, fmt.Errorf("%s: pattern %d is empty", src.Path, i)
+		}
+	}
+	return patterns, nil
+}
+
+// Validate checks the generated rules compile, unlike the Linguist lists they are written for RE2
+func (g GeneratedRulesNonPgtype) Validate() error {
+	var problems []error
+	for _, p := range g.Paths {
+		if _, err := regexp.Compile(p); err != nil {
+			problems = append(problems, fmt.Errorf("path pattern %q: %w", p, err))
+		}
+	}
+	for _, ext := range g.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			problems = append(problems, fmt.Errorf("extension %q does not start with a dot", ext))
+		}
+	}
+	for i, rule := range g.Content {
+		if rule.Name == "" {
+			problems = append(problems, fmt.Errorf("content rule %d has no name", i))
+		}
+		if len(rule.Extensions) == 0 {
+			problems = append(problems, fmt.Errorf("content rule %s has no extensions", rule.Name))
+		}
+		if rule.Pattern == "" && rule.MinAverageLineLength <= 0 {
+			problems = append(problems, fmt.Errorf("content rule %s needs a pattern or min_average_line_length", rule.Name))
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				problems = append(problems, fmt.Errorf("content rule %s: %w", rule.Name, err))
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// SyncPathRules replaces the path rule tables with rules in a single transaction
+func SyncPathRules(ctx context.Context, conn TxBeginner, rules *PathRulesNonPgtype) (*PathRulesSyncSummary, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback path rules sync transaction")
+		}
+	}()
+	q := New(tx)
+
+	if err := q.DeletePathRules(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete path rules: %w", err)
+	}
+	if err := q.DeleteGeneratedExtensions(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete generated extensions: %w", err)
+	}
+	if err := q.DeleteGeneratedContentRules(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete generated content rules: %w", err)
+	}
+
+	for kind, patterns := range map[PathRuleKind][]string{
+		PathRuleKindVendor:        rules.Vendor,
+		PathRuleKindDocumentation: rules.Documentation,
+		PathRuleKindGenerated:     rules.Generated.Paths,
+	} {
+		if err := q.CreatePathRules(ctx, CreatePathRulesParams{Kind: kind, Patterns: patterns}); err != nil {
+			return nil, fmt.Errorf("failed to insert %s path rules: %w", kind, err)
+		}
+	}
+	if err := q.CreateGeneratedExtensions(ctx, rules.Generated.Extensions); err != nil {
+		return nil, fmt.Errorf("failed to insert generated extensions: %w", err)
+	}
+	for i, rule := range rules.Generated.Content {
+		params := CreateGeneratedContentRuleParams{
+			Name:       rule.Name,
+			Position:   int32(i), //nolint: gosec
+			Extensions: rule.Extensions,
+			Lines:      rule.Lines,
+		}
+		if rule.Pattern != "" {
+			params.Pattern = pgtype.Text{String: rule.Pattern, Valid: true}
+		}
+		if rule.MinAverageLineLength > 0 {
+			params.MinAverageLineLength = pgtype.Int4{Int32: rule.MinAverageLineLength, Valid: true}
+		}
+		if err := q.CreateGeneratedContentRule(ctx, params); err != nil {
+			return nil, fmt.Errorf("failed to insert generated content rule %s: %w", rule.Name, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit path rules sync: %w", err)
+	}
+	return &PathRulesSyncSummary{
+		Vendor:              len(rules.Vendor),
+		Documentation:       len(rules.Documentation),
+		Generated:           len(rules.Generated.Paths),
+		GeneratedExtensions: len(rules.Generated.Extensions),
+		GeneratedContent:    len(rules.Generated.Content),
+	}, nil
+}
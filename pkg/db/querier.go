@@ -9,8 +9,52 @@ import (
 )
 
 type Querier interface {
-	GetLanguageCount(ctx context.Context) (int64, error)
+	AdvisoryLock(ctx context.Context, key int64) error
+	AdvisoryUnlock(ctx context.Context, key int64) (bool, error)
+	CreateGeneratedContentRule(ctx context.Context, arg CreateGeneratedContentRuleParams) error
+	CreateGeneratedExtensions(ctx context.Context, extensions []string) error
+	CreateGrammars(ctx context.Context, arg CreateGrammarsParams) error
+	CreateHeuristicDisambiguation(ctx context.Context, arg CreateHeuristicDisambiguationParams) (int32, error)
+	CreateHeuristicNamedPattern(ctx context.Context, arg CreateHeuristicNamedPatternParams) error
+	CreateHeuristicRule(ctx context.Context, arg CreateHeuristicRuleParams) (int32, error)
+	CreateIngestRun(ctx context.Context, arg CreateIngestRunParams) (int32, error)
+	CreateLanguageStaging(ctx context.Context) error
+	CreatePathRules(ctx context.Context, arg CreatePathRulesParams) error
+	DeleteGeneratedContentRules(ctx context.Context) error
+	DeleteGeneratedExtensions(ctx context.Context) error
+	DeleteGrammars(ctx context.Context) error
+	DeleteHeuristicDisambiguations(ctx context.Context) error
+	DeleteHeuristicNamedPatterns(ctx context.Context) error
+	DeleteLanguageLookups(ctx context.Context) error
+	DeleteLanguages(ctx context.Context, languageIds []int32) error
+	DeletePathRules(ctx context.Context) error
+	FinishIngestRun(ctx context.Context, arg FinishIngestRunParams) error
+	GetGeneratedContentRules(ctx context.Context) ([]GeneratedContentRule, error)
+	GetGeneratedExtensions(ctx context.Context) ([]string, error)
+	GetHeuristicDisambiguations(ctx context.Context) ([]HeuristicDisambiguation, error)
+	GetHeuristicNamedPatterns(ctx context.Context) ([]HeuristicNamedPattern, error)
+	GetHeuristicRules(ctx context.Context) ([]HeuristicRule, error)
+	GetIngestRun(ctx context.Context, id int32) (IngestRun, error)
+	GetLanguageByAlias(ctx context.Context, alias string) (Language, error)
+	GetLanguageChildren(ctx context.Context, languageID int32) ([]Language, error)
+	GetLanguageGrammars(ctx context.Context) ([]GetLanguageGrammarsRow, error)
+	GetLanguageRoot(ctx context.Context, languageID int32) (Language, error)
+	GetLanguageSnapshot(ctx context.Context, ingestRunID int32) ([]LanguageSnapshot, error)
+	GetLanguageSyntax(ctx context.Context) ([]LanguageSyntax, error)
 	GetLanguages(ctx context.Context) ([]Language, error)
+	GetLanguagesByExtension(ctx context.Context, extension string) ([]Language, error)
+	GetLanguagesByFilename(ctx context.Context, filename string) ([]Language, error)
+	GetLanguagesByInterpreter(ctx context.Context, interpreter string) ([]Language, error)
+	GetLanguagesWithUnknownScope(ctx context.Context) ([]GetLanguagesWithUnknownScopeRow, error)
+	GetPathRules(ctx context.Context) ([]PathRule, error)
+	GetUnresolvedLanguageGroups(ctx context.Context) ([]GetUnresolvedLanguageGroupsRow, error)
+	InsertLanguageLookups(ctx context.Context) error
+	ListIngestRuns(ctx context.Context) ([]IngestRun, error)
+	MergeLanguageStaging(ctx context.Context) error
+	ResolveLanguageParents(ctx context.Context) error
+	SnapshotLanguages(ctx context.Context, ingestRunID int32) error
+	TryAdvisoryLock(ctx context.Context, key int64) (bool, error)
+	UpsertLanguageSyntax(ctx context.Context, arg UpsertLanguageSyntaxParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -7,22 +7,772 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const advisoryLock = `-- name: AdvisoryLock :exec
+SELECT pg_advisory_lock($1::bigint)
+`
+
+func (q *Queries) AdvisoryLock(ctx context.Context, key int64) error {
+	_, err := q.db.Exec(ctx, advisoryLock, key)
+	return err
+}
+
+const advisoryUnlock = `-- name: AdvisoryUnlock :one
+SELECT pg_advisory_unlock($1::bigint)
+`
+
+func (q *Queries) AdvisoryUnlock(ctx context.Context, key int64) (bool, error) {
+	row := q.db.QueryRow(ctx, advisoryUnlock, key)
+	var pg_advisory_unlock bool
+	err := row.Scan(&pg_advisory_unlock)
+	return pg_advisory_unlock, err
+}
+
+const createGeneratedContentRule = `-- name: CreateGeneratedContentRule :exec
+INSERT INTO generated_content_rules (
+    name,
+    position,
+    extensions,
+    pattern,
+    lines,
+    min_average_line_length
+  )
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateGeneratedContentRuleParams struct {
+	Name                 string
+	Position             int32
+	Extensions           []string
+	Pattern              pgtype.Text
+	Lines                int32
+	MinAverageLineLength pgtype.Int4
+}
+
+func (q *Queries) CreateGeneratedContentRule(ctx context.Context, arg CreateGeneratedContentRuleParams) error {
+	_, err := q.db.Exec(ctx, createGeneratedContentRule,
+		arg.Name,
+		arg.Position,
+		arg.Extensions,
+		arg.Pattern,
+		arg.Lines,
+		arg.MinAverageLineLength,
+	)
+	return err
+}
+
+const createGeneratedExtensions = `-- name: CreateGeneratedExtensions :exec
+INSERT INTO generated_extensions (extension)
+SELECT unnest($1::text [])
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) CreateGeneratedExtensions(ctx context.Context, extensions []string) error {
+	_, err := q.db.Exec(ctx, createGeneratedExtensions, extensions)
+	return err
+}
+
+const createGrammars = `-- name: CreateGrammars :exec
+INSERT INTO grammars (scope, repository)
+SELECT unnest($1::text []),
+  unnest($2::text [])
+`
+
+type CreateGrammarsParams struct {
+	Scopes       []string
+	Repositories []string
+}
+
+func (q *Queries) CreateGrammars(ctx context.Context, arg CreateGrammarsParams) error {
+	_, err := q.db.Exec(ctx, createGrammars, arg.Scopes, arg.Repositories)
+	return err
+}
+
+const createHeuristicDisambiguation = `-- name: CreateHeuristicDisambiguation :one
+INSERT INTO heuristic_disambiguations (position, extensions)
+VALUES ($1, $2)
+RETURNING id
+`
+
+type CreateHeuristicDisambiguationParams struct {
+	Position   int32
+	Extensions []string
+}
+
+func (q *Queries) CreateHeuristicDisambiguation(ctx context.Context, arg CreateHeuristicDisambiguationParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createHeuristicDisambiguation, arg.Position, arg.Extensions)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createHeuristicNamedPattern = `-- name: CreateHeuristicNamedPattern :exec
+INSERT INTO heuristic_named_patterns (name, pattern)
+VALUES ($1, $2)
+`
+
+type CreateHeuristicNamedPatternParams struct {
+	Name    string
+	Pattern []string
+}
+
+func (q *Queries) CreateHeuristicNamedPattern(ctx context.Context, arg CreateHeuristicNamedPatternParams) error {
+	_, err := q.db.Exec(ctx, createHeuristicNamedPattern, arg.Name, arg.Pattern)
+	return err
+}
+
+const createHeuristicRule = `-- name: CreateHeuristicRule :one
+INSERT INTO heuristic_rules (
+    disambiguation_id,
+    parent_rule_id,
+    position,
+    languages,
+    pattern,
+    negative_pattern,
+    named_pattern
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id
+`
+
+type CreateHeuristicRuleParams struct {
+	DisambiguationID int32
+	ParentRuleID     pgtype.Int4
+	Position         int32
+	Languages        []string
+	Pattern          []string
+	NegativePattern  []string
+	NamedPattern     pgtype.Text
+}
+
+func (q *Queries) CreateHeuristicRule(ctx context.Context, arg CreateHeuristicRuleParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createHeuristicRule,
+		arg.DisambiguationID,
+		arg.ParentRuleID,
+		arg.Position,
+		arg.Languages,
+		arg.Pattern,
+		arg.NegativePattern,
+		arg.NamedPattern,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createIngestRun = `-- name: CreateIngestRun :one
+INSERT INTO ingest_runs (source, sha256, etag)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+type CreateIngestRunParams struct {
+	Source string
+	Sha256 pgtype.Text
+	Etag   pgtype.Text
+}
+
+func (q *Queries) CreateIngestRun(ctx context.Context, arg CreateIngestRunParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createIngestRun, arg.Source, arg.Sha256, arg.Etag)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createLanguageStaging = `-- name: CreateLanguageStaging :exec
+CREATE TEMPORARY TABLE languages_staging ON COMMIT DROP AS
+SELECT name,
+  fs_name,
+  "type",
+  aliases,
+  ace_mode,
+  codemirror_mode,
+  codemirror_mime_type,
+  wrap,
+  extensions,
+  filenames,
+  interpreters,
+  language_id,
+  color,
+  tm_scope,
+  "group",
+  ingest_run_id,
+  custom,
+  overlay
+FROM languages WITH NO DATA
+`
+
+func (q *Queries) CreateLanguageStaging(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, createLanguageStaging)
+	return err
+}
+
+const createPathRules = `-- name: CreatePathRules :exec
+INSERT INTO path_rules (kind, position, pattern)
+SELECT $1::path_rule_kind,
+  p.position - 1,
+  p.pattern
+FROM unnest($2::text []) WITH ORDINALITY AS p(pattern, position)
+`
+
+type CreatePathRulesParams struct {
+	Kind     PathRuleKind
+	Patterns []string
+}
+
+func (q *Queries) CreatePathRules(ctx context.Context, arg CreatePathRulesParams) error {
+	_, err := q.db.Exec(ctx, createPathRules, arg.Kind, arg.Patterns)
+	return err
+}
+
+const deleteGeneratedContentRules = `-- name: DeleteGeneratedContentRules :exec
+DELETE FROM generated_content_rules
+`
+
+func (q *Queries) DeleteGeneratedContentRules(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteGeneratedContentRules)
+	return err
+}
+
+const deleteGeneratedExtensions = `-- name: DeleteGeneratedExtensions :exec
+DELETE FROM generated_extensions
+`
+
+func (q *Queries) DeleteGeneratedExtensions(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteGeneratedExtensions)
+	return err
+}
+
+const deleteGrammars = `-- name: DeleteGrammars :exec
+DELETE FROM grammars
+`
+
+func (q *Queries) DeleteGrammars(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteGrammars)
+	return err
+}
+
+const deleteHeuristicDisambiguations = `-- name: DeleteHeuristicDisambiguations :exec
+DELETE FROM heuristic_disambiguations
+`
+
+func (q *Queries) DeleteHeuristicDisambiguations(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteHeuristicDisambiguations)
+	return err
+}
+
+const deleteHeuristicNamedPatterns = `-- name: DeleteHeuristicNamedPatterns :exec
+DELETE FROM heuristic_named_patterns
+`
+
+func (q *Queries) DeleteHeuristicNamedPatterns(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteHeuristicNamedPatterns)
+	return err
+}
+
+const deleteLanguageLookups = `-- name: DeleteLanguageLookups :exec
+DELETE FROM language_lookups
+`
+
+func (q *Queries) DeleteLanguageLookups(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteLanguageLookups)
+	return err
+}
+
+const deleteLanguages = `-- name: DeleteLanguages :exec
+DELETE FROM languages
+WHERE language_id = ANY($1::int [])
+`
+
+func (q *Queries) DeleteLanguages(ctx context.Context, languageIds []int32) error {
+	_, err := q.db.Exec(ctx, deleteLanguages, languageIds)
+	return err
+}
+
+const deletePathRules = `-- name: DeletePathRules :exec
+DELETE FROM path_rules
+`
+
+func (q *Queries) DeletePathRules(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deletePathRules)
+	return err
+}
+
+const finishIngestRun = `-- name: FinishIngestRun :exec
+UPDATE ingest_runs
+SET added_count = $2,
+  changed_count = $3,
+  removed_count = $4,
+  total_count = $5,
+  finished_at = clock_timestamp()
+WHERE id = $1
+`
+
+type FinishIngestRunParams struct {
+	ID           int32
+	AddedCount   int32
+	ChangedCount int32
+	RemovedCount int32
+	TotalCount   int32
+}
+
+func (q *Queries) FinishIngestRun(ctx context.Context, arg FinishIngestRunParams) error {
+	_, err := q.db.Exec(ctx, finishIngestRun,
+		arg.ID,
+		arg.AddedCount,
+		arg.ChangedCount,
+		arg.RemovedCount,
+		arg.TotalCount,
+	)
+	return err
+}
+
+const getGeneratedContentRules = `-- name: GetGeneratedContentRules :many
+SELECT id, name, position, extensions, pattern, lines, min_average_line_length
+FROM generated_content_rules
+ORDER BY position
+`
+
+func (q *Queries) GetGeneratedContentRules(ctx context.Context) ([]GeneratedContentRule, error) {
+	rows, err := q.db.Query(ctx, getGeneratedContentRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GeneratedContentRule
+	for rows.Next() {
+		var i GeneratedContentRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Position,
+			&i.Extensions,
+			&i.Pattern,
+			&i.Lines,
+			&i.MinAverageLineLength,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGeneratedExtensions = `-- name: GetGeneratedExtensions :many
+SELECT extension
+FROM generated_extensions
+ORDER BY extension
+`
+
+func (q *Queries) GetGeneratedExtensions(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, getGeneratedExtensions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var extension string
+		if err := rows.Scan(&extension); err != nil {
+			return nil, err
+		}
+		items = append(items, extension)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeuristicDisambiguations = `-- name: GetHeuristicDisambiguations :many
+SELECT id, position, extensions
+FROM heuristic_disambiguations
+ORDER BY position
+`
+
+func (q *Queries) GetHeuristicDisambiguations(ctx context.Context) ([]HeuristicDisambiguation, error) {
+	rows, err := q.db.Query(ctx, getHeuristicDisambiguations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HeuristicDisambiguation
+	for rows.Next() {
+		var i HeuristicDisambiguation
+		if err := rows.Scan(
+			&i.ID,
+			&i.Position,
+			&i.Extensions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeuristicNamedPatterns = `-- name: GetHeuristicNamedPatterns :many
+SELECT name, pattern
+FROM heuristic_named_patterns
+ORDER BY name
+`
+
+func (q *Queries) GetHeuristicNamedPatterns(ctx context.Context) ([]HeuristicNamedPattern, error) {
+	rows, err := q.db.Query(ctx, getHeuristicNamedPatterns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HeuristicNamedPattern
+	for rows.Next() {
+		var i HeuristicNamedPattern
+		if err := rows.Scan(
+			&i.Name,
+			&i.Pattern,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeuristicRules = `-- name: GetHeuristicRules :many
+SELECT id, disambiguation_id, parent_rule_id, position, languages, pattern, negative_pattern, named_pattern
+FROM heuristic_rules
+ORDER BY disambiguation_id,
+  parent_rule_id NULLS FIRST,
+  position
+`
+
+func (q *Queries) GetHeuristicRules(ctx context.Context) ([]HeuristicRule, error) {
+	rows, err := q.db.Query(ctx, getHeuristicRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HeuristicRule
+	for rows.Next() {
+		var i HeuristicRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.DisambiguationID,
+			&i.ParentRuleID,
+			&i.Position,
+			&i.Languages,
+			&i.Pattern,
+			&i.NegativePattern,
+			&i.NamedPattern,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIngestRun = `-- name: GetIngestRun :one
+SELECT id, source, sha256, etag, added_count, changed_count, removed_count, total_count, started_at, finished_at
+FROM ingest_runs
+WHERE id = $1
+`
+
+func (q *Queries) GetIngestRun(ctx context.Context, id int32) (IngestRun, error) {
+	row := q.db.QueryRow(ctx, getIngestRun, id)
+	var i IngestRun
+	err := row.Scan(
+		&i.ID,
+		&i.Source,
+		&i.Sha256,
+		&i.Etag,
+		&i.AddedCount,
+		&i.ChangedCount,
+		&i.RemovedCount,
+		&i.TotalCount,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getLanguageByAlias = `-- name: GetLanguageByAlias :one
+SELECT l.id, l.name, l.fs_name, l.type, l.aliases, l.ace_mode, l.codemirror_mode, l.codemirror_mime_type, l.wrap, l.extensions, l.filenames, l.interpreters, l.language_id, l.color, l.tm_scope, l."group", l.ingest_run_id, l.custom, l.parent_id, l.overlay
+FROM language_lookups k
+  JOIN languages l ON l.language_id = k.language_id
+WHERE k.kind = 'alias'
+  AND k.value = lower($1::text)
+ORDER BY k.position,
+  l.name
+LIMIT 1
+`
+
+func (q *Queries) GetLanguageByAlias(ctx context.Context, alias string) (Language, error) {
+	row := q.db.QueryRow(ctx, getLanguageByAlias, alias)
+	var i Language
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.FsName,
+		&i.Type,
+		&i.Aliases,
+		&i.AceMode,
+		&i.CodemirrorMode,
+		&i.CodemirrorMimeType,
+		&i.Wrap,
+		&i.Extensions,
+		&i.Filenames,
+		&i.Interpreters,
+		&i.LanguageID,
+		&i.Color,
+		&i.TmScope,
+		&i.Group,
+		&i.IngestRunID,
+		&i.Custom,
+		&i.ParentID,
+		&i.Overlay,
+	)
+	return i, err
+}
+
+const getLanguageChildren = `-- name: GetLanguageChildren :many
+SELECT id, name, fs_name, type, aliases, ace_mode, codemirror_mode, codemirror_mime_type, wrap, extensions, filenames, interpreters, language_id, color, tm_scope, "group", ingest_run_id, custom, parent_id, overlay
+FROM languages
+WHERE parent_id = $1::int
+ORDER BY name
+`
+
+func (q *Queries) GetLanguageChildren(ctx context.Context, languageID int32) ([]Language, error) {
+	rows, err := q.db.Query(ctx, getLanguageChildren, languageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Language
+	for rows.Next() {
+		var i Language
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.FsName,
+			&i.Type,
+			&i.Aliases,
+			&i.AceMode,
+			&i.CodemirrorMode,
+			&i.CodemirrorMimeType,
+			&i.Wrap,
+			&i.Extensions,
+			&i.Filenames,
+			&i.Interpreters,
+			&i.LanguageID,
+			&i.Color,
+			&i.TmScope,
+			&i.Group,
+			&i.IngestRunID,
+			&i.Custom,
+			&i.ParentID,
+			&i.Overlay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLanguageGrammars = `-- name: GetLanguageGrammars :many
+SELECT l.language_id,
+  l.name,
+  l.tm_scope,
+  g.repository
+FROM languages l
+  LEFT JOIN grammars g ON g.scope = l.tm_scope
+ORDER BY l.name
+`
+
+type GetLanguageGrammarsRow struct {
+	LanguageID int32
+	Name       string
+	TmScope    pgtype.Text
+	Repository pgtype.Text
+}
+
+func (q *Queries) GetLanguageGrammars(ctx context.Context) ([]GetLanguageGrammarsRow, error) {
+	rows, err := q.db.Query(ctx, getLanguageGrammars)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLanguageGrammarsRow
+	for rows.Next() {
+		var i GetLanguageGrammarsRow
+		if err := rows.Scan(
+			&i.LanguageID,
+			&i.Name,
+			&i.TmScope,
+			&i.Repository,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLanguageRoot = `-- name: GetLanguageRoot :one
+WITH RECURSIVE ancestors AS (
+  SELECT l.language_id,
+    l.parent_id,
+    ARRAY [l.language_id] AS path
+  FROM languages l
+  WHERE l.language_id = $1::int
+  UNION ALL
+  SELECT p.language_id,
+    p.parent_id,
+    a.path || p.language_id
+  FROM ancestors a
+    JOIN languages p ON p.language_id = a.parent_id
+  WHERE NOT p.language_id = ANY(a.path)
 )
+SELECT l.id, l.name, l.fs_name, l.type, l.aliases, l.ace_mode, l.codemirror_mode, l.codemirror_mime_type, l.wrap, l.extensions, l.filenames, l.interpreters, l.language_id, l.color, l.tm_scope, l."group", l.ingest_run_id, l.custom, l.parent_id, l.overlay
+FROM ancestors a
+  JOIN languages l ON l.language_id = a.language_id
+ORDER BY cardinality(a.path) DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLanguageRoot(ctx context.Context, languageID int32) (Language, error) {
+	row := q.db.QueryRow(ctx, getLanguageRoot, languageID)
+	var i Language
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.FsName,
+		&i.Type,
+		&i.Aliases,
+		&i.AceMode,
+		&i.CodemirrorMode,
+		&i.CodemirrorMimeType,
+		&i.Wrap,
+		&i.Extensions,
+		&i.Filenames,
+		&i.Interpreters,
+		&i.LanguageID,
+		&i.Color,
+		&i.TmScope,
+		&i.Group,
+		&i.IngestRunID,
+		&i.Custom,
+		&i.ParentID,
+		&i.Overlay,
+	)
+	return i, err
+}
+
+const getLanguageSnapshot = `-- name: GetLanguageSnapshot :many
+SELECT ingest_run_id, name, fs_name, type, aliases, ace_mode, codemirror_mode, codemirror_mime_type, wrap, extensions, filenames, interpreters, language_id, color, tm_scope, "group", custom, overlay
+FROM language_snapshots
+WHERE ingest_run_id = $1
+`
+
+func (q *Queries) GetLanguageSnapshot(ctx context.Context, ingestRunID int32) ([]LanguageSnapshot, error) {
+	rows, err := q.db.Query(ctx, getLanguageSnapshot, ingestRunID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LanguageSnapshot
+	for rows.Next() {
+		var i LanguageSnapshot
+		if err := rows.Scan(
+			&i.IngestRunID,
+			&i.Name,
+			&i.FsName,
+			&i.Type,
+			&i.Aliases,
+			&i.AceMode,
+			&i.CodemirrorMode,
+			&i.CodemirrorMimeType,
+			&i.Wrap,
+			&i.Extensions,
+			&i.Filenames,
+			&i.Interpreters,
+			&i.LanguageID,
+			&i.Color,
+			&i.TmScope,
+			&i.Group,
+			&i.Custom,
+			&i.Overlay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
 
-const getLanguageCount = `-- name: GetLanguageCount :one
-SELECT COUNT(id)
-FROM languages
+const getLanguageSyntax = `-- name: GetLanguageSyntax :many
+SELECT language_id, catalog, catalog_name, line_comments, block_comment_starts, block_comment_ends, nested_block_comments, quote_starts, quote_ends, imported_at
+FROM language_syntax
+ORDER BY language_id
 `
 
-func (q *Queries) GetLanguageCount(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, getLanguageCount)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+func (q *Queries) GetLanguageSyntax(ctx context.Context) ([]LanguageSyntax, error) {
+	rows, err := q.db.Query(ctx, getLanguageSyntax)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LanguageSyntax
+	for rows.Next() {
+		var i LanguageSyntax
+		if err := rows.Scan(
+			&i.LanguageID,
+			&i.Catalog,
+			&i.CatalogName,
+			&i.LineComments,
+			&i.BlockCommentStarts,
+			&i.BlockCommentEnds,
+			&i.NestedBlockComments,
+			&i.QuoteStarts,
+			&i.QuoteEnds,
+			&i.ImportedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getLanguages = `-- name: GetLanguages :many
-SELECT id, name, fs_name, type, aliases, ace_mode, codemirror_mode, codemirror_mime_type, wrap, extensions, filenames, interpreters, language_id, color, tm_scope, "group"
+SELECT id, name, fs_name, type, aliases, ace_mode, codemirror_mode, codemirror_mime_type, wrap, extensions, filenames, interpreters, language_id, color, tm_scope, "group", ingest_run_id, custom, parent_id, overlay
 FROM languages
 `
 
@@ -52,6 +802,340 @@ func (q *Queries) GetLanguages(ctx context.Context) ([]Language, error) {
 			&i.Color,
 			&i.TmScope,
 			&i.Group,
+			&i.IngestRunID,
+			&i.Custom,
+			&i.ParentID,
+			&i.Overlay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLanguagesByExtension = `-- name: GetLanguagesByExtension :many
+SELECT l.id, l.name, l.fs_name, l.type, l.aliases, l.ace_mode, l.codemirror_mode, l.codemirror_mime_type, l.wrap, l.extensions, l.filenames, l.interpreters, l.language_id, l.color, l.tm_scope, l."group", l.ingest_run_id, l.custom, l.parent_id, l.overlay
+FROM language_lookups k
+  JOIN languages l ON l.language_id = k.language_id
+WHERE k.kind = 'extension'
+  AND lower(k.value) = lower($1::text)
+ORDER BY k.position,
+  l.name
+`
+
+func (q *Queries) GetLanguagesByExtension(ctx context.Context, extension string) ([]Language, error) {
+	rows, err := q.db.Query(ctx, getLanguagesByExtension, extension)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Language
+	for rows.Next() {
+		var i Language
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.FsName,
+			&i.Type,
+			&i.Aliases,
+			&i.AceMode,
+			&i.CodemirrorMode,
+			&i.CodemirrorMimeType,
+			&i.Wrap,
+			&i.Extensions,
+			&i.Filenames,
+			&i.Interpreters,
+			&i.LanguageID,
+			&i.Color,
+			&i.TmScope,
+			&i.Group,
+			&i.IngestRunID,
+			&i.Custom,
+			&i.ParentID,
+			&i.Overlay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLanguagesByFilename = `-- name: GetLanguagesByFilename :many
+SELECT l.id, l.name, l.fs_name, l.type, l.aliases, l.ace_mode, l.codemirror_mode, l.codemirror_mime_type, l.wrap, l.extensions, l.filenames, l.interpreters, l.language_id, l.color, l.tm_scope, l."group", l.ingest_run_id, l.custom, l.parent_id, l.overlay
+FROM language_lookups k
+  JOIN languages l ON l.language_id = k.language_id
+WHERE k.kind = 'filename'
+  AND k.value = $1::text
+ORDER BY l.name
+`
+
+func (q *Queries) GetLanguagesByFilename(ctx context.Context, filename string) ([]Language, error) {
+	rows, err := q.db.Query(ctx, getLanguagesByFilename, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Language
+	for rows.Next() {
+		var i Language
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.FsName,
+			&i.Type,
+			&i.Aliases,
+			&i.AceMode,
+			&i.CodemirrorMode,
+			&i.CodemirrorMimeType,
+			&i.Wrap,
+			&i.Extensions,
+			&i.Filenames,
+			&i.Interpreters,
+			&i.LanguageID,
+			&i.Color,
+			&i.TmScope,
+			&i.Group,
+			&i.IngestRunID,
+			&i.Custom,
+			&i.ParentID,
+			&i.Overlay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLanguagesByInterpreter = `-- name: GetLanguagesByInterpreter :many
+SELECT l.id, l.name, l.fs_name, l.type, l.aliases, l.ace_mode, l.codemirror_mode, l.codemirror_mime_type, l.wrap, l.extensions, l.filenames, l.interpreters, l.language_id, l.color, l.tm_scope, l."group", l.ingest_run_id, l.custom, l.parent_id, l.overlay
+FROM language_lookups k
+  JOIN languages l ON l.language_id = k.language_id
+WHERE k.kind = 'interpreter'
+  AND k.value = $1::text
+ORDER BY l.name
+`
+
+func (q *Queries) GetLanguagesByInterpreter(ctx context.Context, interpreter string) ([]Language, error) {
+	rows, err := q.db.Query(ctx, getLanguagesByInterpreter, interpreter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Language
+	for rows.Next() {
+		var i Language
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.FsName,
+			&i.Type,
+			&i.Aliases,
+			&i.AceMode,
+			&i.CodemirrorMode,
+			&i.CodemirrorMimeType,
+			&i.Wrap,
+			&i.Extensions,
+			&i.Filenames,
+			&i.Interpreters,
+			&i.LanguageID,
+			&i.Color,
+			&i.TmScope,
+			&i.Group,
+			&i.IngestRunID,
+			&i.Custom,
+			&i.ParentID,
+			&i.Overlay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLanguagesWithUnknownScope = `-- name: GetLanguagesWithUnknownScope :many
+SELECT l.language_id,
+  l.name,
+  l.tm_scope
+FROM languages l
+WHERE l.tm_scope IS NOT NULL
+  AND l.tm_scope <> 'none'
+  AND NOT EXISTS (
+    SELECT 1
+    FROM grammars g
+    WHERE g.scope = l.tm_scope
+  )
+ORDER BY l.name
+`
+
+type GetLanguagesWithUnknownScopeRow struct {
+	LanguageID int32
+	Name       string
+	TmScope    pgtype.Text
+}
+
+func (q *Queries) GetLanguagesWithUnknownScope(ctx context.Context) ([]GetLanguagesWithUnknownScopeRow, error) {
+	rows, err := q.db.Query(ctx, getLanguagesWithUnknownScope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLanguagesWithUnknownScopeRow
+	for rows.Next() {
+		var i GetLanguagesWithUnknownScopeRow
+		if err := rows.Scan(
+			&i.LanguageID,
+			&i.Name,
+			&i.TmScope,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPathRules = `-- name: GetPathRules :many
+SELECT id, kind, position, pattern
+FROM path_rules
+ORDER BY kind,
+  position
+`
+
+func (q *Queries) GetPathRules(ctx context.Context) ([]PathRule, error) {
+	rows, err := q.db.Query(ctx, getPathRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PathRule
+	for rows.Next() {
+		var i PathRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Position,
+			&i.Pattern,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnresolvedLanguageGroups = `-- name: GetUnresolvedLanguageGroups :many
+SELECT name,
+  "group"
+FROM languages
+WHERE "group" IS NOT NULL
+  AND parent_id IS NULL
+ORDER BY name
+`
+
+type GetUnresolvedLanguageGroupsRow struct {
+	Name  string
+	Group pgtype.Text
+}
+
+func (q *Queries) GetUnresolvedLanguageGroups(ctx context.Context) ([]GetUnresolvedLanguageGroupsRow, error) {
+	rows, err := q.db.Query(ctx, getUnresolvedLanguageGroups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnresolvedLanguageGroupsRow
+	for rows.Next() {
+		var i GetUnresolvedLanguageGroupsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Group,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertLanguageLookups = `-- name: InsertLanguageLookups :exec
+INSERT INTO language_lookups (language_id, kind, value, position)
+SELECT l.language_id, 'extension'::language_lookup_kind, e.value, e.position
+FROM languages l,
+  unnest(l.extensions) WITH ORDINALITY AS e(value, position)
+UNION ALL
+SELECT l.language_id, 'filename'::language_lookup_kind, f.value, f.position
+FROM languages l,
+  unnest(l.filenames) WITH ORDINALITY AS f(value, position)
+UNION ALL
+SELECT l.language_id, 'interpreter'::language_lookup_kind, i.value, i.position
+FROM languages l,
+  unnest(l.interpreters) WITH ORDINALITY AS i(value, position)
+UNION ALL
+SELECT l.language_id, 'alias'::language_lookup_kind, lower(l.name), 0
+FROM languages l
+UNION ALL
+SELECT l.language_id, 'alias'::language_lookup_kind, lower(a.value), a.position
+FROM languages l,
+  unnest(l.aliases) WITH ORDINALITY AS a(value, position)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) InsertLanguageLookups(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, insertLanguageLookups)
+	return err
+}
+
+const listIngestRuns = `-- name: ListIngestRuns :many
+SELECT id, source, sha256, etag, added_count, changed_count, removed_count, total_count, started_at, finished_at
+FROM ingest_runs
+ORDER BY id DESC
+`
+
+func (q *Queries) ListIngestRuns(ctx context.Context) ([]IngestRun, error) {
+	rows, err := q.db.Query(ctx, listIngestRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []IngestRun
+	for rows.Next() {
+		var i IngestRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.Source,
+			&i.Sha256,
+			&i.Etag,
+			&i.AddedCount,
+			&i.ChangedCount,
+			&i.RemovedCount,
+			&i.TotalCount,
+			&i.StartedAt,
+			&i.FinishedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -62,3 +1146,199 @@ func (q *Queries) GetLanguages(ctx context.Context) ([]Language, error) {
 	}
 	return items, nil
 }
+
+const mergeLanguageStaging = `-- name: MergeLanguageStaging :exec
+INSERT INTO languages (
+    name,
+    fs_name,
+    "type",
+    aliases,
+    ace_mode,
+    codemirror_mode,
+    codemirror_mime_type,
+    wrap,
+    extensions,
+    filenames,
+    interpreters,
+    language_id,
+    color,
+    tm_scope,
+    "group",
+    ingest_run_id,
+    custom,
+    overlay
+  )
+SELECT name,
+  fs_name,
+  "type",
+  aliases,
+  ace_mode,
+  codemirror_mode,
+  codemirror_mime_type,
+  wrap,
+  extensions,
+  filenames,
+  interpreters,
+  language_id,
+  color,
+  tm_scope,
+  "group",
+  ingest_run_id,
+  custom,
+  overlay
+FROM languages_staging ON CONFLICT (language_id) DO
+UPDATE
+SET name = EXCLUDED.name,
+  fs_name = EXCLUDED.fs_name,
+  "type" = EXCLUDED."type",
+  aliases = EXCLUDED.aliases,
+  ace_mode = EXCLUDED.ace_mode,
+  codemirror_mode = EXCLUDED.codemirror_mode,
+  codemirror_mime_type = EXCLUDED.codemirror_mime_type,
+  wrap = EXCLUDED.wrap,
+  extensions = EXCLUDED.extensions,
+  filenames = EXCLUDED.filenames,
+  interpreters = EXCLUDED.interpreters,
+  color = EXCLUDED.color,
+  tm_scope = EXCLUDED.tm_scope,
+  "group" = EXCLUDED."group",
+  ingest_run_id = EXCLUDED.ingest_run_id,
+  custom = EXCLUDED.custom,
+  overlay = EXCLUDED.overlay
+`
+
+func (q *Queries) MergeLanguageStaging(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, mergeLanguageStaging)
+	return err
+}
+
+const resolveLanguageParents = `-- name: ResolveLanguageParents :exec
+UPDATE languages c
+SET parent_id = (
+    SELECT p.language_id
+    FROM languages p
+    WHERE p.name = c."group"
+    LIMIT 1
+  )
+WHERE c.parent_id IS DISTINCT FROM (
+    SELECT p.language_id
+    FROM languages p
+    WHERE p.name = c."group"
+    LIMIT 1
+  )
+`
+
+func (q *Queries) ResolveLanguageParents(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, resolveLanguageParents)
+	return err
+}
+
+const snapshotLanguages = `-- name: SnapshotLanguages :exec
+INSERT INTO language_snapshots (
+    ingest_run_id,
+    name,
+    fs_name,
+    "type",
+    aliases,
+    ace_mode,
+    codemirror_mode,
+    codemirror_mime_type,
+    wrap,
+    extensions,
+    filenames,
+    interpreters,
+    language_id,
+    color,
+    tm_scope,
+    "group",
+    custom,
+    overlay
+  )
+SELECT $1::int,
+  name,
+  fs_name,
+  "type",
+  aliases,
+  ace_mode,
+  codemirror_mode,
+  codemirror_mime_type,
+  wrap,
+  extensions,
+  filenames,
+  interpreters,
+  language_id,
+  color,
+  tm_scope,
+  "group",
+  custom,
+  overlay
+FROM languages
+`
+
+func (q *Queries) SnapshotLanguages(ctx context.Context, ingestRunID int32) error {
+	_, err := q.db.Exec(ctx, snapshotLanguages, ingestRunID)
+	return err
+}
+
+const tryAdvisoryLock = `-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_lock($1::bigint)
+`
+
+func (q *Queries) TryAdvisoryLock(ctx context.Context, key int64) (bool, error) {
+	row := q.db.QueryRow(ctx, tryAdvisoryLock, key)
+	var pg_try_advisory_lock bool
+	err := row.Scan(&pg_try_advisory_lock)
+	return pg_try_advisory_lock, err
+}
+
+const upsertLanguageSyntax = `-- name: UpsertLanguageSyntax :exec
+INSERT INTO language_syntax (
+    language_id,
+    catalog,
+    catalog_name,
+    line_comments,
+    block_comment_starts,
+    block_comment_ends,
+    nested_block_comments,
+    quote_starts,
+    quote_ends
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (language_id) DO
+UPDATE
+SET catalog = EXCLUDED.catalog,
+  catalog_name = EXCLUDED.catalog_name,
+  line_comments = EXCLUDED.line_comments,
+  block_comment_starts = EXCLUDED.block_comment_starts,
+  block_comment_ends = EXCLUDED.block_comment_ends,
+  nested_block_comments = EXCLUDED.nested_block_comments,
+  quote_starts = EXCLUDED.quote_starts,
+  quote_ends = EXCLUDED.quote_ends,
+  imported_at = now()
+`
+
+type UpsertLanguageSyntaxParams struct {
+	LanguageID          int32
+	Catalog             string
+	CatalogName         string
+	LineComments        []string
+	BlockCommentStarts  []string
+	BlockCommentEnds    []string
+	NestedBlockComments bool
+	QuoteStarts         []string
+	QuoteEnds           []string
+}
+
+func (q *Queries) UpsertLanguageSyntax(ctx context.Context, arg UpsertLanguageSyntaxParams) error {
+	_, err := q.db.Exec(ctx, upsertLanguageSyntax,
+		arg.LanguageID,
+		arg.Catalog,
+		arg.CatalogName,
+		arg.LineComments,
+		arg.BlockCommentStarts,
+		arg.BlockCommentEnds,
+		arg.NestedBlockComments,
+		arg.QuoteStarts,
+		arg.QuoteEnds,
+	)
+	return err
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// SourceEmbedded selects the Linguist snapshot compiled into the binary
+	SourceEmbedded = "embedded"
+	// SourceStdin reads the source from standard input
+	SourceStdin = "-"
+)
+
+//go:embed linguist/languages.yml
+var embeddedLanguages []byte
+
+// Source is a fetched Linguist data file
+type Source struct {
+	// Path the data was fetched from
+	Path string
+	// Data is the raw file content
+	Data []byte
+	// ETag returned by the server, empty for non http sources
+	ETag string
+	// Cached is set when the server answered 304 Not Modified and Data was read from the cache
+	Cached bool
+}
+
+// SHA256 returns the hex encoded sha256 of the fetched data
+func (s *Source) SHA256() string {
+	sum := sha256.Sum256(s.Data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fetchSource reads a Linguist data file from path, which is either an http(s) URL, a file:// URL,
+// a plain local path, SourceStdin or SourceEmbedded. embedded is served for SourceEmbedded,
+// opts only applies to http(s) URLs.
+func fetchSource(ctx context.Context, path string, embedded []byte, opts FetchOptions) (*Source, error) {
+	src := &Source{Path: path}
+	switch path {
+	case SourceEmbedded:
+		if embedded == nil {
+			return nil, fmt.Errorf("no embedded snapshot is available for this source")
+		}
+		src.Data = embedded
+		return src, nil
+	case SourceStdin:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		src.Data = data
+		return src, nil
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme == "" {
+		return readLocalSource(src, path)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "file":
+		// file:///abs/path and file://relative/path are both accepted
+		return readLocalSource(src, u.Host+u.Path)
+	case "http", "https":
+		return fetchRemoteSource(ctx, src, opts)
+	default:
+		return nil, fmt.Errorf("unsupported source scheme %q", u.Scheme)
+	}
+}
+
+func readLocalSource(src *Source, path string) (*Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	src.Data = data
+	return src, nil
+}
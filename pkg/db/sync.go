@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// TxBeginner is anything that can open a transaction, *pgx.Conn and *pgxpool.Pool both qualify
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// LanguageSyncSummary holds the ingest run recorded by SyncLanguages and the names of the languages it touched
+type LanguageSyncSummary struct {
+	RunID   int32
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+// Empty reports whether the sync left the languages table untouched
+func (s LanguageSyncSummary) Empty() bool {
+	return len(s.Added) == 0 && len(s.Changed) == 0 && len(s.Removed) == 0
+}
+
+// Equal reports whether two languages carry the same data, ignoring the database assigned ID and ingest run
+func (l Language) Equal(o Language) bool {
+	return len(l.Diff(o)) == 0
+}
+
+// SyncOptions tells SyncLanguages which rows missing from the synced languages it keeps
+type SyncOptions struct {
+	// Exact deletes every row missing from the languages, custom ones included, so the table ends up holding
+	// exactly the synced languages as a rollback needs
+	Exact bool
+	// Overlays are the overlay files merged into the languages. A custom row added by one of them is deleted once
+	// it no longer provides it, custom rows of other overlays are kept.
+	Overlays []string
+}
+
+// keeps reports whether a row missing from the synced languages stays in the table
+func (o SyncOptions) keeps(lang Language) bool {
+	if !lang.Custom || o.Exact {
+		return false
+	}
+	return !lang.Overlay.Valid || !slices.Contains(o.Overlays, lang.Overlay.String)
+}
+
+// SyncLanguages diffs languages against the rows in the languages table keyed by language_id, then
+// deletes the ones missing from languages and merges new and changed ones through a staging table,
+// all in a single transaction so readers never see a half loaded table.
+// Groups are then resolved to parent_id, a group naming no language fails the whole sync,
+// and the language_lookups rows are rebuilt from the resulting table.
+// Custom rows missing from languages are kept unless opts say otherwise, see SyncOptions.
+// The sync is recorded as an ingest run describing src, and the resulting table is snapshotted under that run.
+func SyncLanguages(ctx context.Context, conn TxBeginner, languages []Language, src *Source, opts SyncOptions) (*LanguageSyncSummary, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback language sync transaction")
+		}
+	}()
+	q := New(tx)
+
+	run := CreateIngestRunParams{Source: src.Path}
+	if src.Data != nil {
+		run.Sha256 = pgtype.Text{String: src.SHA256(), Valid: true}
+	}
+	if src.ETag != "" {
+		run.Etag = pgtype.Text{String: src.ETag, Valid: true}
+	}
+	runID, err := q.CreateIngestRun(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ingest run: %w", err)
+	}
+	touchedBy := pgtype.Int4{Int32: runID, Valid: true}
+
+	current, err := q.GetLanguages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current languages: %w", err)
+	}
+	existing := make(map[int32]Language, len(current))
+	for _, lang := range current {
+		existing[lang.LanguageID] = lang
+	}
+
+	summary := &LanguageSyncSummary{RunID: runID}
+	// added and changed languages are copied into a staging table and merged in one statement
+	var staged []Language
+	for _, lang := range languages {
+		lang.IngestRunID = touchedBy
+		old, ok := existing[lang.LanguageID]
+		if !ok {
+			staged = append(staged, lang)
+			summary.Added = append(summary.Added, lang.Name)
+			continue
+		}
+		delete(existing, lang.LanguageID)
+		if old.Equal(lang) {
+			continue
+		}
+		staged = append(staged, lang)
+		summary.Changed = append(summary.Changed, lang.Name)
+	}
+
+	var removed []int32
+	kept := 0
+	for id, lang := range existing {
+		if opts.keeps(lang) {
+			// custom rows of overlays left out of this sync are kept, the upstream data never mentions them
+			kept++
+			continue
+		}
+		removed = append(removed, id)
+		summary.Removed = append(summary.Removed, lang.Name)
+	}
+	if len(removed) > 0 {
+		if err := q.DeleteLanguages(ctx, removed); err != nil {
+			return nil, fmt.Errorf("failed to delete removed languages: %w", err)
+		}
+	}
+
+	if len(staged) > 0 {
+		if err := q.CreateLanguageStaging(ctx); err != nil {
+			return nil, fmt.Errorf("failed to create languages staging table: %w", err)
+		}
+		if _, err := tx.CopyFrom(
+			ctx,
+			[]string{"languages_staging"},
+			LanguageColumns(),
+			&LanguageCopyFrom{Languages: staged},
+		); err != nil {
+			return nil, fmt.Errorf("failed to copy languages into staging table: %w", err)
+		}
+		if err := q.MergeLanguageStaging(ctx); err != nil {
+			return nil, fmt.Errorf("failed to merge staged languages: %w", err)
+		}
+	}
+
+	if err := resolveLanguageGroups(ctx, q); err != nil {
+		return nil, err
+	}
+
+	// the lookup tables are derived from the language arrays, rebuilding them is cheaper than diffing
+	if err := q.DeleteLanguageLookups(ctx); err != nil {
+		return nil, fmt.Errorf("failed to clear language lookups: %w", err)
+	}
+	if err := q.InsertLanguageLookups(ctx); err != nil {
+		return nil, fmt.Errorf("failed to rebuild language lookups: %w", err)
+	}
+
+	if err := q.SnapshotLanguages(ctx, runID); err != nil {
+		return nil, fmt.Errorf("failed to snapshot languages: %w", err)
+	}
+	if err := q.FinishIngestRun(ctx, FinishIngestRunParams{
+		ID:           runID,
+		AddedCount:   int32(len(summary.Added)),    //nolint: gosec
+		ChangedCount: int32(len(summary.Changed)),  //nolint: gosec
+		RemovedCount: int32(len(summary.Removed)),  //nolint: gosec
+		TotalCount:   int32(len(languages) + kept), //nolint: gosec
+	}); err != nil {
+		return nil, fmt.Errorf("failed to finish ingest run: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit language sync: %w", err)
+	}
+	sort.Strings(summary.Added)
+	sort.Strings(summary.Changed)
+	sort.Strings(summary.Removed)
+	return summary, nil
+}
+
+// resolveLanguageGroups points parent_id at the language each group names, failing when a group names no language
+func resolveLanguageGroups(ctx context.Context, q Querier) error {
+	if err := q.ResolveLanguageParents(ctx); err != nil {
+		return fmt.Errorf("failed to resolve language groups: %w", err)
+	}
+	unresolved, err := q.GetUnresolvedLanguageGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check language groups: %w", err)
+	}
+	if len(unresolved) == 0 {
+		return nil
+	}
+	problems := make([]string, 0, len(unresolved))
+	for _, row := range unresolved {
+		problems = append(problems, fmt.Sprintf("%s is in unknown group %q", row.Name, row.Group.String))
+	}
+	return fmt.Errorf("languages reference groups that do not exist: %s", strings.Join(problems, ", "))
+}
+
+// RollbackLanguages restores the languages table to the snapshot taken by an earlier ingest run, custom rows
+// included, so rows missing from the snapshot are deleted whether or not they are custom.
+// The rollback is itself synced and recorded as a new ingest run.
+func RollbackLanguages(ctx context.Context, conn TxBeginner, q Querier, runID int32) (*LanguageSyncSummary, error) {
+	run, err := q.GetIngestRun(ctx, runID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ingest run %d does not exist", runID)
+		}
+		return nil, fmt.Errorf("failed to get ingest run %d: %w", runID, err)
+	}
+	if !run.FinishedAt.Valid {
+		return nil, fmt.Errorf("ingest run %d never finished", runID)
+	}
+	snapshot, err := q.GetLanguageSnapshot(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot of ingest run %d: %w", runID, err)
+	}
+	if len(snapshot) == 0 {
+		return nil, fmt.Errorf("ingest run %d has no snapshot", runID)
+	}
+	languages := make([]Language, 0, len(snapshot))
+	for _, s := range snapshot {
+		languages = append(languages, s.ToLanguage())
+	}
+	return SyncLanguages(ctx, conn, languages, &Source{Path: fmt.Sprintf("rollback:%d", runID)}, SyncOptions{Exact: true})
+}
+
+// ToLanguage converts a snapshotted row back into a languages row
+func (s LanguageSnapshot) ToLanguage() Language {
+	return Language{
+		Name:               s.Name,
+		FsName:             s.FsName,
+		Type:               s.Type,
+		Aliases:            s.Aliases,
+		AceMode:            s.AceMode,
+		CodemirrorMode:     s.CodemirrorMode,
+		CodemirrorMimeType: s.CodemirrorMimeType,
+		Wrap:               s.Wrap,
+		Extensions:         s.Extensions,
+		Filenames:          s.Filenames,
+		Interpreters:       s.Interpreters,
+		LanguageID:         s.LanguageID,
+		Color:              s.Color,
+		TmScope:            s.TmScope,
+		Group:              s.Group,
+		Custom:             s.Custom,
+		Overlay:            s.Overlay,
+	}
+}
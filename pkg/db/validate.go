@@ -0,0 +1,140 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// colorPattern matches the CSS hex colors that fit in the CHAR(7) color column
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// LanguageLoadError lists the languages.yml entries that failed to decode or validate, keyed by language name,
+// and the warnings about entries that were still loaded
+type LanguageLoadError struct {
+	// Path the languages were loaded from
+	Path string
+	// Failures holds every problem found for a language key, such entries are left out
+	Failures map[string][]error
+	// Warnings holds problems that do not leave an entry out, such as a tm_scope no grammar provides.
+	// Only a strict load fails on them.
+	Warnings map[string][]error
+}
+
+func (e *LanguageLoadError) add(key string, errs ...error) {
+	if e.Failures == nil {
+		e.Failures = make(map[string][]error)
+	}
+	e.Failures[key] = append(e.Failures[key], errs...)
+}
+
+func (e *LanguageLoadError) warn(key string, errs ...error) {
+	if e.Warnings == nil {
+		e.Warnings = make(map[string][]error)
+	}
+	e.Warnings[key] = append(e.Warnings[key], errs...)
+}
+
+// empty reports whether nothing was found, neither failures nor warnings
+func (e *LanguageLoadError) empty() bool {
+	return len(e.Failures) == 0 && len(e.Warnings) == 0
+}
+
+// Keys returns the failing language keys in sorted order
+func (e *LanguageLoadError) Keys() []string {
+	return sortedKeys(e.Failures)
+}
+
+// WarningKeys returns the language keys with warnings in sorted order
+func (e *LanguageLoadError) WarningKeys() []string {
+	return sortedKeys(e.Warnings)
+}
+
+func sortedKeys(m map[string][]error) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LogSkipped warns about every entry left out by a non-strict load, and every warning about an entry it kept
+func (e *LanguageLoadError) LogSkipped() {
+	for _, key := range e.Keys() {
+		log.Warn().
+			Str("key", key).
+			Errs("problems", e.Failures[key]).
+			Msg("skipped invalid language entry")
+	}
+	for _, key := range e.WarningKeys() {
+		log.Warn().
+			Str("key", key).
+			Errs("problems", e.Warnings[key]).
+			Msg("loaded language entry with warnings")
+	}
+}
+
+// Error implements the error interface, listing every failing key with its problems, then every warning
+func (e *LanguageLoadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d invalid language entries", len(e.Failures))
+	if len(e.Warnings) > 0 {
+		fmt.Fprintf(&b, " and %d with warnings", len(e.Warnings))
+	}
+	fmt.Fprintf(&b, " in %s", e.Path)
+	for _, k := range e.Keys() {
+		fmt.Fprintf(&b, "; %s: %s", k, errors.Join(e.Failures[k]...).Error())
+	}
+	for _, k := range e.WarningKeys() {
+		fmt.Fprintf(&b, "; %s (warning): %s", k, errors.Join(e.Warnings[k]...).Error())
+	}
+	// yaml decode errors span several lines, keep the message on one
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// parseLanguageType maps a languages.yml type value onto the language_type enum
+func parseLanguageType(s string) (LanguageType, bool) {
+	switch LanguageType(s) {
+	case LanguageTypeData, LanguageTypeProgramming, LanguageTypeMarkup, LanguageTypeProse:
+		return LanguageType(s), true
+	}
+	return "", false
+}
+
+// Validate checks a single language entry against what the languages table can hold, returning every problem found
+func (l LanguageNonPgtype) Validate() []error {
+	var problems []error
+	if l.Type != "" {
+		if _, ok := parseLanguageType(l.Type); !ok {
+			problems = append(problems, fmt.Errorf("unknown type %q", l.Type))
+		}
+	}
+	if l.Color != "" && !colorPattern.MatchString(l.Color) {
+		problems = append(problems, fmt.Errorf("color %q is not in #RRGGBB form", l.Color))
+	}
+	// a missing extensions key decodes to nil, an explicit empty list does not
+	if l.Extensions != nil && len(l.Extensions) == 0 {
+		problems = append(problems, errors.New("extensions list is empty"))
+	}
+	for _, ext := range l.Extensions {
+		if ext == "" {
+			problems = append(problems, errors.New("extensions list contains an empty extension"))
+			break
+		}
+	}
+	return problems
+}
+
+// validateScope checks that the tm_scope of l is one of scopes, a language without a grammar uses "none".
+// A miss is only a warning, an organization may highlight the language with a grammar Linguist does not know about.
+func (l LanguageNonPgtype) validateScope(scopes map[string]bool) error {
+	if l.TmScope == "" || l.TmScope == "none" || scopes[l.TmScope] {
+		return nil
+	}
+	return fmt.Errorf("tm_scope %q is not provided by any grammar", l.TmScope)
+}
@@ -0,0 +1,181 @@
+package detect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// sniffBytes is how much of a file Classify looks at, git looks at the same amount for NUL bytes
+	sniffBytes = 8000
+	// maxInvalidUTF8 is the share of bytes outside valid UTF-8 above which content without a BOM is binary.
+	// Latin-1 prose stays well under it, compressed or encrypted data is far above it.
+	maxInvalidUTF8 = 0.3
+)
+
+// Encodings of text content
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingUTF32LE = "utf-32le"
+	EncodingUTF32BE = "utf-32be"
+)
+
+// ContentType tells text from binary content
+type ContentType struct {
+	Binary bool `json:"binary"`
+	// MIME is a MIME type such as image/png, or text/plain with the charset of text content
+	MIME string `json:"mime"`
+	// Encoding is set for text, BOM-less text that is not valid UTF-8 is still reported as utf-8
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type magicNumber struct {
+	offset int
+	magic  []byte
+	mime   string
+}
+
+// magicNumbers are checked in order, longer signatures sharing a prefix with a shorter one come first
+var magicNumbers = []magicNumber{
+	{0, []byte("\x7fELF"), "application/x-elf"},
+	{0, []byte("\x89PNG\r\n\x1a\n"), "image/png"},
+	{0, []byte("PK\x03\x04"), "application/zip"},
+	{0, []byte("PK\x05\x06"), "application/zip"},
+	{0, []byte("PK\x07\x08"), "application/zip"},
+	{0, []byte("%PDF-"), "application/pdf"},
+	{0, []byte("GIF87a"), "image/gif"},
+	{0, []byte("GIF89a"), "image/gif"},
+	{0, []byte("\xff\xd8\xff"), "image/jpeg"},
+	{0, []byte("II*\x00"), "image/tiff"},
+	{0, []byte("MM\x00*"), "image/tiff"},
+	{8, []byte("WEBP"), "image/webp"},
+	{0, []byte("\x00\x00\x01\x00"), "image/x-icon"},
+	{0, []byte("\x1f\x8b"), "application/gzip"},
+	{0, []byte("BZh"), "application/x-bzip2"},
+	{0, []byte("\xfd7zXZ\x00"), "application/x-xz"},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "application/x-7z-compressed"},
+	{0, []byte("\x28\xb5\x2f\xfd"), "application/zstd"},
+	{257, []byte("ustar"), "application/x-tar"},
+	{0, []byte("\xfe\xed\xfa\xce"), "application/x-mach-binary"},
+	{0, []byte("\xfe\xed\xfa\xcf"), "application/x-mach-binary"},
+	{0, []byte("\xce\xfa\xed\xfe"), "application/x-mach-binary"},
+	{0, []byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary"},
+	{0, []byte("\xca\xfe\xba\xbe"), "application/java-vm"},
+	{0, []byte("MZ"), "application/vnd.microsoft.portable-executable"},
+	{0, []byte("\x00asm"), "application/wasm"},
+	{0, []byte("SQLite format 3\x00"), "application/vnd.sqlite3"},
+	{0, []byte("OggS"), "audio/ogg"},
+	{0, []byte("ID3"), "audio/mpeg"},
+	{0, []byte("fLaC"), "audio/flac"},
+	{4, []byte("ftyp"), "video/mp4"},
+	{0, []byte("wOFF"), "font/woff"},
+	{0, []byte("wOF2"), "font/woff2"},
+}
+
+// byteOrderMarks are checked in order, UTF-32 LE starts with the UTF-16 LE mark
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte("\xff\xfe\x00\x00"), EncodingUTF32LE},
+	{[]byte("\x00\x00\xfe\xff"), EncodingUTF32BE},
+	{[]byte("\xff\xfe"), EncodingUTF16LE},
+	{[]byte("\xfe\xff"), EncodingUTF16BE},
+	{[]byte("\xef\xbb\xbf"), EncodingUTF8},
+}
+
+// Classify tells whether content is text or binary from its first sniffBytes: a byte order mark makes it text,
+// a known magic number makes it binary, and otherwise a NUL byte or too much invalid UTF-8 makes it binary.
+// Magic numbers made of printable ASCII, such as BZh or MZ, start ordinary text too, so they only name the MIME
+// type of content the text checks already found binary.
+func Classify(content []byte) ContentType {
+	for _, bom := range byteOrderMarks {
+		if bytes.HasPrefix(content, bom.bom) {
+			return ContentType{MIME: "text/plain; charset=" + bom.encoding, Encoding: bom.encoding}
+		}
+	}
+	if mime, ok := sniffMagic(content, false); ok {
+		return ContentType{Binary: true, MIME: mime}
+	}
+	head := content[:min(len(content), sniffBytes)]
+	if bytes.IndexByte(head, 0) >= 0 {
+		return binaryContent(content)
+	}
+	invalid := 0
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		// a multi-byte rune cut off by the sniff limit is not invalid
+		if r == utf8.RuneError && size == 1 && utf8.FullRune(head) {
+			invalid++
+		}
+		head = head[size:]
+	}
+	if len(content) > 0 && float64(invalid)/float64(min(len(content), sniffBytes)) > maxInvalidUTF8 {
+		return binaryContent(content)
+	}
+	return ContentType{MIME: "text/plain; charset=utf-8", Encoding: EncodingUTF8}
+}
+
+// binaryContent is the content type of content that failed the text checks
+func binaryContent(content []byte) ContentType {
+	mime, ok := sniffMagic(content, true)
+	if !ok {
+		mime = "application/octet-stream"
+	}
+	return ContentType{Binary: true, MIME: mime}
+}
+
+// sniffMagic returns the MIME type of the first magic number content starts with, looking only at the printable
+// magic numbers when printable is set and only at the others otherwise
+func sniffMagic(content []byte, printable bool) (string, bool) {
+	for _, m := range magicNumbers {
+		if m.printable() != printable {
+			continue
+		}
+		if len(content) >= m.offset+len(m.magic) && bytes.Equal(content[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.mime, true
+		}
+	}
+	return "", false
+}
+
+// printable reports whether the magic number is made of printable ASCII only
+func (m magicNumber) printable() bool {
+	for _, b := range m.magic {
+		if b < 0x20 || b > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// toUTF8 returns text content as UTF-8 without its byte order mark, so the strategies only ever see UTF-8
+func toUTF8(content []byte, encoding string) []byte {
+	switch encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		var order binary.ByteOrder = binary.LittleEndian
+		if encoding == EncodingUTF16BE {
+			order = binary.BigEndian
+		}
+		units := make([]uint16, 0, len(content)/2)
+		for i := 2; i+1 < len(content); i += 2 {
+			units = append(units, order.Uint16(content[i:]))
+		}
+		return []byte(string(utf16.Decode(units)))
+	case EncodingUTF32LE, EncodingUTF32BE:
+		var order binary.ByteOrder = binary.LittleEndian
+		if encoding == EncodingUTF32BE {
+			order = binary.BigEndian
+		}
+		var out []byte
+		for i := 4; i+3 < len(content); i += 4 {
+			out = utf8.AppendRune(out, rune(order.Uint32(content[i:])))
+		}
+		return out
+	}
+	return bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+}
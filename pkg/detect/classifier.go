@@ -0,0 +1,275 @@
+package detect
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/caner-cetin/seer/pkg/db"
+)
+
+const (
+	// classifierVersion is bumped whenever Tokenize or the model layout changes, older models are refused
+	classifierVersion = 1
+	// classifierConsiderBytes is how much of a file the classifier looks at, the same limit Linguist uses
+	classifierConsiderBytes = 50 * 1024
+)
+
+// Classifier is a naive Bayes model over the tokens of sample files, keyed by language name
+type Classifier struct {
+	Version int `json:"version"`
+	// Documents counts the samples of each language
+	Documents map[string]int `json:"documents"`
+	// Tokens counts how often each token occurs in the samples of each language
+	Tokens map[string]map[string]int `json:"tokens"`
+	// LanguageTokens is the number of tokens in the samples of each language
+	LanguageTokens map[string]int `json:"language_tokens"`
+	TotalDocuments int            `json:"total_documents"`
+	TotalTokens    int            `json:"total_tokens"`
+}
+
+// Score is the probability the classifier gives a language, the scores of one Classify call sum to 1
+type Score struct {
+	Language    string
+	Probability float64
+}
+
+// TrainSummary describes what Train read
+type TrainSummary struct {
+	Languages int
+	Samples   int
+	// UnknownDirs are sample directories matching no language fs_name or name, they are not trained on
+	UnknownDirs []string
+}
+
+// NewClassifier returns an untrained classifier
+func NewClassifier() *Classifier {
+	return &Classifier{
+		Version:        classifierVersion,
+		Documents:      make(map[string]int),
+		Tokens:         make(map[string]map[string]int),
+		LanguageTokens: make(map[string]int),
+	}
+}
+
+// DefaultClassifierPath returns seer/classifier.json.gz under the user cache directory,
+// or an empty string when there is no such directory
+func DefaultClassifierPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "seer", "classifier.json.gz")
+}
+
+// Train builds a classifier from a samples directory laid out like Linguist's, one directory per language
+// named after its fs_name or, when that is unset, its name. Files are read from any depth below it.
+func Train(samplesDir string, languages []db.Language) (*Classifier, *TrainSummary, error) {
+	byDir := make(map[string]string, len(languages))
+	for _, lang := range languages {
+		dir := lang.Name
+		// languages without an fs_name are stored with an empty one
+		if lang.FsName.Valid && lang.FsName.String != "" {
+			dir = lang.FsName.String
+		}
+		byDir[dir] = lang.Name
+	}
+	entries, err := os.ReadDir(samplesDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read samples directory: %w", err)
+	}
+	c := NewClassifier()
+	summary := &TrainSummary{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		language, ok := byDir[entry.Name()]
+		if !ok {
+			summary.UnknownDirs = append(summary.UnknownDirs, entry.Name())
+			continue
+		}
+		samples := 0
+		err := filepath.WalkDir(filepath.Join(samplesDir, entry.Name()), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if c.Add(language, content) {
+				samples++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read samples of %s: %w", language, err)
+		}
+		if samples > 0 {
+			summary.Languages++
+			summary.Samples += samples
+		}
+	}
+	// unseen tokens score 1/TotalTokens, a model without any tokens would score everything NaN
+	if c.TotalTokens == 0 {
+		return nil, nil, fmt.Errorf("no text samples found in %s", samplesDir)
+	}
+	return c, summary, nil
+}
+
+// Add trains the classifier on one sample of language and reports whether it did,
+// binary samples and samples without any tokens are ignored
+func (c *Classifier) Add(language string, content []byte) bool {
+	contentType := Classify(content)
+	if contentType.Binary {
+		return false
+	}
+	tokens := Tokenize(toUTF8(content, contentType.Encoding))
+	if len(tokens) == 0 {
+		return false
+	}
+	c.Documents[language]++
+	c.TotalDocuments++
+	counts, ok := c.Tokens[language]
+	if !ok {
+		counts = make(map[string]int)
+		c.Tokens[language] = counts
+	}
+	for _, token := range tokens {
+		counts[token]++
+	}
+	c.LanguageTokens[language] += len(tokens)
+	c.TotalTokens += len(tokens)
+	return true
+}
+
+// Classify scores content against languages, best first. Languages the classifier was not trained on are left out.
+func (c *Classifier) Classify(content []byte, languages []string) []Score {
+	tokens := Tokenize(content)
+	type logScore struct {
+		language string
+		score    float64
+	}
+	var scores []logScore
+	for _, language := range languages {
+		documents, ok := c.Documents[language]
+		if !ok {
+			continue
+		}
+		// log P(language) + sum of log P(token|language), unseen tokens get 1/TotalTokens as Linguist's classifier does
+		score := math.Log(float64(documents) / float64(c.TotalDocuments))
+		counts := c.Tokens[language]
+		for _, token := range tokens {
+			if n := counts[token]; n > 0 {
+				score += math.Log(float64(n) / float64(c.LanguageTokens[language]))
+			} else {
+				score += math.Log(1 / float64(c.TotalTokens))
+			}
+		}
+		scores = append(scores, logScore{language: language, score: score})
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+	// normalise with the best score factored out, the raw log scores are far too small to exponentiate
+	var sum float64
+	for _, s := range scores {
+		sum += math.Exp(s.score - scores[0].score)
+	}
+	result := make([]Score, 0, len(scores))
+	for _, s := range scores {
+		result = append(result, Score{Language: s.language, Probability: math.Exp(s.score-scores[0].score) / sum})
+	}
+	return result
+}
+
+// Save writes the classifier to path as gzipped JSON, replacing any previous model only once it is fully written
+func (c *Classifier) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create classifier directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".classifier-*")
+	if err != nil {
+		return fmt.Errorf("failed to create classifier file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	zw := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(zw).Encode(c); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode classifier: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compress classifier: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write classifier: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move classifier into place: %w", err)
+	}
+	return nil
+}
+
+// LoadClassifier reads a classifier written by Save
+func LoadClassifier(path string) (*Classifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open classifier: %w", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress classifier %s: %w", path, err)
+	}
+	var c Classifier
+	if err := json.NewDecoder(zr).Decode(&c); err != nil {
+		return nil, fmt.Errorf("failed to decode classifier %s: %w", path, err)
+	}
+	if c.Version != classifierVersion {
+		return nil, fmt.Errorf("classifier %s has version %d, this seer reads version %d, train it again", path, c.Version, classifierVersion)
+	}
+	if c.TotalTokens == 0 {
+		return nil, fmt.Errorf("classifier %s was trained on no tokens, train it again", path)
+	}
+	return &c, nil
+}
+
+func (d *Detector) byClassifierStrategy(_ string, content, _ []byte, candidates []Candidate) []Candidate {
+	if d.classifier == nil || len(candidates) < 2 {
+		return nil
+	}
+	if len(content) > classifierConsiderBytes {
+		content = content[:classifierConsiderBytes]
+	}
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		names = append(names, c.Name)
+	}
+	var found []Candidate
+	for _, score := range d.classifier.Classify(content, names) {
+		id, ok := d.byName[strings.ToLower(score.Language)]
+		if !ok {
+			continue
+		}
+		found = append(found, Candidate{
+			LanguageID:  id,
+			Name:        d.languages[id].Name,
+			Strategy:    StrategyClassifier,
+			Reason:      "naive bayes",
+			Probability: score.Probability,
+		})
+	}
+	return found
+}
@@ -0,0 +1,287 @@
+// Package detect answers which language a file is written in, from the languages and heuristics seer ingested from Linguist
+package detect
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/caner-cetin/seer/pkg/db"
+)
+
+// Strategy names the detection step that produced a candidate
+type Strategy string
+
+// Strategies in the order Detect tries them, following Linguist
+const (
+	StrategyModeline   Strategy = "modeline"
+	StrategyFilename   Strategy = "filename"
+	StrategyShebang    Strategy = "shebang"
+	StrategyExtension  Strategy = "extension"
+	StrategyHeuristics Strategy = "heuristics"
+	StrategyClassifier Strategy = "classifier"
+)
+
+// ConsiderBytes is the most of the head of a file any strategy looks at
+const ConsiderBytes = max(heuristicsConsiderBytes, classifierConsiderBytes)
+
+// TailBytes is how much of the end of a file DetectSample needs, enough for the last lines searched for a modeline
+const TailBytes = 4 * 1024
+
+// StrategyAttribute marks a language set by a linguist-language gitattribute, Detect never produces it
+const StrategyAttribute Strategy = "gitattributes"
+
+// Candidate is a language a file may be written in
+type Candidate struct {
+	LanguageID int32    `json:"language_id"`
+	Name       string   `json:"name"`
+	Strategy   Strategy `json:"strategy"`
+	// Reason is the modeline, filename, interpreter, extension or rule that matched
+	Reason string `json:"reason"`
+	// Probability is set by the classifier, the probabilities of the candidates of one result sum to 1
+	Probability float64 `json:"probability,omitempty"`
+}
+
+// Result holds the candidates left after every strategy ran, best first.
+// A single candidate means a strategy settled the language, several mean none could tell them apart.
+// Binary content has no candidates, its Content tells what it is instead.
+type Result struct {
+	Content    ContentType `json:"content"`
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Language returns the best candidate, false when nothing matched
+func (r Result) Language() (Candidate, bool) {
+	if len(r.Candidates) == 0 {
+		return Candidate{}, false
+	}
+	return r.Candidates[0], true
+}
+
+// Data is what a Detector is built from, see New
+type Data struct {
+	Languages       []db.Language
+	Disambiguations []db.HeuristicDisambiguation
+	HeuristicRules  []db.HeuristicRule
+	NamedPatterns   []db.HeuristicNamedPattern
+	// Classifier is optional, without one files heuristics cannot settle keep all their candidates
+	Classifier *Classifier
+}
+
+// Detector holds in-memory lookup tables of the languages table, it is safe for concurrent use
+type Detector struct {
+	languages     map[int32]db.Language
+	byName        map[string]int32
+	byAlias       map[string]int32
+	byFilename    map[string][]int32
+	byExtension   map[string][]int32
+	byInterpreter map[string][]int32
+	heuristics    *heuristics
+	classifier    *Classifier
+}
+
+// strategy narrows the candidates found so far, returning nil when it has nothing to say.
+// tail is the end of a file whose content was cut short, see DetectSample, and nil otherwise.
+type strategy func(d *Detector, path string, content, tail []byte, candidates []Candidate) []Candidate
+
+var strategies = []strategy{
+	// a modeline is the author stating the language, it outranks everything guessed from the name
+	(*Detector).byModelineStrategy,
+	(*Detector).byFilenameStrategy,
+	(*Detector).byShebangStrategy,
+	(*Detector).byExtensionStrategy,
+	(*Detector).byHeuristicsStrategy,
+	(*Detector).byClassifierStrategy,
+}
+
+// Load reads everything a Detector needs through q, except the classifier which lives in a file
+func Load(ctx context.Context, q db.Querier) (*Data, error) {
+	var data Data
+	var err error
+	if data.Languages, err = q.GetLanguages(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get languages: %w", err)
+	}
+	if data.Disambiguations, err = q.GetHeuristicDisambiguations(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get heuristic disambiguations: %w", err)
+	}
+	if data.HeuristicRules, err = q.GetHeuristicRules(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get heuristic rules: %w", err)
+	}
+	if data.NamedPatterns, err = q.GetHeuristicNamedPatterns(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get heuristic named patterns: %w", err)
+	}
+	return &data, nil
+}
+
+// New loads the languages and heuristics through q and builds a Detector from them
+func New(ctx context.Context, q db.Querier) (*Detector, error) {
+	data, err := Load(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromData(data), nil
+}
+
+// NewFromData builds a Detector from rows that were already loaded
+func NewFromData(data *Data) *Detector {
+	d := &Detector{
+		languages:     make(map[int32]db.Language, len(data.Languages)),
+		byName:        make(map[string]int32, len(data.Languages)),
+		byAlias:       make(map[string]int32),
+		byFilename:    make(map[string][]int32),
+		byExtension:   make(map[string][]int32),
+		byInterpreter: make(map[string][]int32),
+	}
+	for _, lang := range data.Languages {
+		d.languages[lang.LanguageID] = lang
+		d.byName[strings.ToLower(lang.Name)] = lang.LanguageID
+		d.byAlias[strings.ToLower(lang.Name)] = lang.LanguageID
+		for _, alias := range lang.Aliases {
+			d.byAlias[strings.ToLower(alias)] = lang.LanguageID
+		}
+		for _, filename := range lang.Filenames {
+			d.byFilename[filename] = append(d.byFilename[filename], lang.LanguageID)
+		}
+		for _, ext := range lang.Extensions {
+			ext = strings.ToLower(ext)
+			d.byExtension[ext] = append(d.byExtension[ext], lang.LanguageID)
+		}
+		for _, interpreter := range lang.Interpreters {
+			d.byInterpreter[interpreter] = append(d.byInterpreter[interpreter], lang.LanguageID)
+		}
+	}
+	d.heuristics = newHeuristics(data, d.byName)
+	d.classifier = data.Classifier
+	return d
+}
+
+// Language returns the language with languageID
+func (d *Detector) Language(languageID int32) (db.Language, bool) {
+	lang, ok := d.languages[languageID]
+	return lang, ok
+}
+
+// Languages returns every language the detector knows, in no particular order
+func (d *Detector) Languages() []db.Language {
+	languages := make([]db.Language, 0, len(d.languages))
+	for _, lang := range d.languages {
+		languages = append(languages, lang)
+	}
+	return languages
+}
+
+// LanguageByAlias finds a language by its name or one of its aliases, ignoring case, as Linguist resolves
+// linguist-language attributes and modelines
+func (d *Detector) LanguageByAlias(alias string) (db.Language, bool) {
+	id, ok := d.byAlias[strings.ToLower(alias)]
+	if !ok {
+		return db.Language{}, false
+	}
+	return d.languages[id], true
+}
+
+// Detect classifies content first and skips binary content. On text it runs every strategy in order over the
+// UTF-8 form of content. A strategy finding a single language settles it, finding several narrows the candidates
+// for the strategies after it, and finding none leaves them as they were.
+func (d *Detector) Detect(path string, content []byte) Result {
+	return d.DetectSample(path, content, nil)
+}
+
+// DetectSample is Detect for a file read in part: head holds its first ConsiderBytes or more and tail its last
+// TailBytes, which is only searched for modelines. A nil tail means head is the whole file. The tail of a UTF-16
+// or UTF-32 file is not searched, it cannot be decoded without knowing where it starts.
+func (d *Detector) DetectSample(path string, head, tail []byte) Result {
+	contentType := Classify(head)
+	if contentType.Binary {
+		return Result{Content: contentType}
+	}
+	content := toUTF8(head, contentType.Encoding)
+	if contentType.Encoding != EncodingUTF8 {
+		tail = nil
+	}
+	var candidates []Candidate
+	for _, s := range strategies {
+		found := s(d, path, content, tail, candidates)
+		if len(found) == 1 {
+			return Result{Content: contentType, Candidates: found}
+		}
+		if len(found) > 1 {
+			candidates = found
+		}
+	}
+	return Result{Content: contentType, Candidates: candidates}
+}
+
+// candidates turns language ids into candidates sorted by name, keeping only those already in previous when
+// there are any. Linguist does the same, so a later strategy can only pick among what earlier ones found.
+func (d *Detector) candidates(ids []int32, previous []Candidate, strategy Strategy, reason string) []Candidate {
+	var found []Candidate
+	seen := make(map[int32]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if len(previous) > 0 && !containsLanguage(previous, id) {
+			continue
+		}
+		found = append(found, Candidate{
+			LanguageID: id,
+			Name:       d.languages[id].Name,
+			Strategy:   strategy,
+			Reason:     reason,
+		})
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found
+}
+
+func containsLanguage(candidates []Candidate, id int32) bool {
+	for _, c := range candidates {
+		if c.LanguageID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Detector) byFilenameStrategy(path string, _, _ []byte, candidates []Candidate) []Candidate {
+	name := filepath.Base(path)
+	return d.candidates(d.byFilename[name], candidates, StrategyFilename, name)
+}
+
+func (d *Detector) byExtensionStrategy(path string, _, _ []byte, candidates []Candidate) []Candidate {
+	for _, ext := range Extensions(path) {
+		ids, ok := d.byExtension[ext]
+		if !ok {
+			continue
+		}
+		found := d.candidates(ids, candidates, StrategyExtension, ext)
+		// languages naming the extension as their primary one rank first
+		sort.SliceStable(found, func(i, j int) bool {
+			return d.isPrimaryExtension(found[i].LanguageID, ext) && !d.isPrimaryExtension(found[j].LanguageID, ext)
+		})
+		return found
+	}
+	return nil
+}
+
+func (d *Detector) isPrimaryExtension(id int32, ext string) bool {
+	exts := d.languages[id].Extensions
+	return len(exts) > 0 && strings.EqualFold(exts[0], ext)
+}
+
+// Extensions returns the lower case extensions of path's file name, longest first,
+// so foo.d.ts yields .d.ts and .ts and a dotfile such as .bashrc yields .bashrc
+func Extensions(path string) []string {
+	name := strings.ToLower(filepath.Base(path))
+	var exts []string
+	for i := 0; i < len(name); i++ {
+		if name[i] == '.' && i < len(name)-1 {
+			exts = append(exts, name[i:])
+		}
+	}
+	return exts
+}
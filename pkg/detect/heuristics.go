@@ -0,0 +1,248 @@
+package detect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/rs/zerolog/log"
+)
+
+// heuristicsConsiderBytes is how much of a file heuristic rules look at, the same limit Linguist uses
+const heuristicsConsiderBytes = 50 * 1024
+
+// rulePattern is a compiled pattern that remembers the Ruby source it was translated from, for explanations
+type rulePattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+// heuristicRule is a compiled heuristics.yml rule, see db.HeuristicRuleNonPgtype for what each field means
+type heuristicRule struct {
+	position     int32
+	languages    []int32
+	patterns     []rulePattern
+	negative     []rulePattern
+	namedPattern string
+	named        []rulePattern
+	and          []heuristicRule
+	// skipped marks a rule that failed to compile, it stays in place so the rules after it cannot claim its files
+	skipped bool
+}
+
+type disambiguation struct {
+	extensions []string
+	rules      []heuristicRule
+}
+
+type heuristics struct {
+	disambiguations []disambiguation
+	skipped         []SkippedRule
+}
+
+// SkippedRule is a heuristic rule the detector could not compile, files it would have settled keep all their candidates
+type SkippedRule struct {
+	Extensions []string
+	Position   int32
+	Err        error
+}
+
+func (s SkippedRule) String() string {
+	return fmt.Sprintf("rule %d for %s: %v", s.Position, strings.Join(s.Extensions, ", "), s.Err)
+}
+
+// newHeuristics compiles every rule once. A rule with a pattern RE2 cannot express, even after translating it
+// from Ruby, is skipped as a whole, since evaluating only part of it could pick the wrong language. It keeps its
+// place, and a file reaching it is left undecided rather than handed to a later rule.
+func newHeuristics(data *Data, byName map[string]int32) *heuristics {
+	h := &heuristics{}
+	named := make(map[string][]rulePattern, len(data.NamedPatterns))
+	namedErrs := make(map[string]error)
+	for _, row := range data.NamedPatterns {
+		patterns, err := compilePatterns(row.Pattern)
+		if err != nil {
+			namedErrs[row.Name] = fmt.Errorf("named pattern %s: %w", row.Name, err)
+			continue
+		}
+		named[row.Name] = patterns
+	}
+
+	children := make(map[int32][]db.HeuristicRule)
+	top := make(map[int32][]db.HeuristicRule)
+	for _, row := range data.HeuristicRules {
+		if row.ParentRuleID.Valid {
+			children[row.ParentRuleID.Int32] = append(children[row.ParentRuleID.Int32], row)
+		} else {
+			top[row.DisambiguationID] = append(top[row.DisambiguationID], row)
+		}
+	}
+
+	var compile func(row db.HeuristicRule) (heuristicRule, error)
+	compile = func(row db.HeuristicRule) (heuristicRule, error) {
+		rule := heuristicRule{position: row.Position}
+		for _, name := range row.Languages {
+			// a language an overlay removed is dropped, the rule still shields the rules after it
+			if id, ok := byName[strings.ToLower(name)]; ok {
+				rule.languages = append(rule.languages, id)
+			}
+		}
+		var err error
+		if rule.patterns, err = compilePatterns(row.Pattern); err != nil {
+			return rule, err
+		}
+		if rule.negative, err = compilePatterns(row.NegativePattern); err != nil {
+			return rule, err
+		}
+		if row.NamedPattern.Valid {
+			rule.namedPattern = row.NamedPattern.String
+			if err := namedErrs[rule.namedPattern]; err != nil {
+				return rule, err
+			}
+			patterns, ok := named[rule.namedPattern]
+			if !ok {
+				return rule, fmt.Errorf("unknown named pattern %s", rule.namedPattern)
+			}
+			rule.named = patterns
+		}
+		for _, child := range children[row.ID] {
+			member, err := compile(child)
+			if err != nil {
+				return rule, err
+			}
+			rule.and = append(rule.and, member)
+		}
+		return rule, nil
+	}
+
+	for _, row := range data.Disambiguations {
+		dis := disambiguation{extensions: row.Extensions}
+		for _, ruleRow := range top[row.ID] {
+			rule, err := compile(ruleRow)
+			if err != nil {
+				h.skipped = append(h.skipped, SkippedRule{Extensions: row.Extensions, Position: ruleRow.Position, Err: err})
+				rule = heuristicRule{position: ruleRow.Position, skipped: true}
+			}
+			dis.rules = append(dis.rules, rule)
+		}
+		h.disambiguations = append(h.disambiguations, dis)
+	}
+	for _, s := range h.skipped {
+		log.Debug().Str("rule", s.String()).Msg("skipped heuristic rule")
+	}
+	return h
+}
+
+// compilePatterns translates Ruby patterns to RE2 and compiles them
+func compilePatterns(sources []string) ([]rulePattern, error) {
+	patterns := make([]rulePattern, 0, len(sources))
+	for _, source := range sources {
+		translated, err := TranslateRubyRegexp(source)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", abbreviate(source), err)
+		}
+		re, err := regexp.Compile(translated)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", abbreviate(source), err)
+		}
+		patterns = append(patterns, rulePattern{source: source, re: re})
+	}
+	return patterns, nil
+}
+
+// SkippedHeuristics returns the heuristic rules the detector could not compile
+func (d *Detector) SkippedHeuristics() []SkippedRule {
+	return d.heuristics.skipped
+}
+
+// matches reports whether the disambiguation covers the file name
+func (dis disambiguation) matches(path string) bool {
+	name := strings.ToLower(path)
+	for _, ext := range dis.extensions {
+		if strings.HasSuffix(name, strings.ToLower(ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// explain reports whether rule matches content, and why. A rule setting nothing always matches.
+func (rule heuristicRule) explain(content []byte) (bool, string) {
+	switch {
+	case len(rule.and) > 0:
+		reasons := make([]string, 0, len(rule.and))
+		for _, member := range rule.and {
+			ok, reason := member.explain(content)
+			if !ok {
+				return false, ""
+			}
+			reasons = append(reasons, reason)
+		}
+		return true, strings.Join(reasons, " and ")
+	case len(rule.patterns) > 0:
+		if p, ok := firstMatch(rule.patterns, content); ok {
+			return true, "pattern " + abbreviate(p.source)
+		}
+		return false, ""
+	case len(rule.negative) > 0:
+		if _, ok := firstMatch(rule.negative, content); ok {
+			return false, ""
+		}
+		sources := make([]string, 0, len(rule.negative))
+		for _, p := range rule.negative {
+			sources = append(sources, abbreviate(p.source))
+		}
+		return true, "no match for " + strings.Join(sources, ", ")
+	case rule.namedPattern != "":
+		if p, ok := firstMatch(rule.named, content); ok {
+			return true, fmt.Sprintf("named pattern %s (%s)", rule.namedPattern, abbreviate(p.source))
+		}
+		return false, ""
+	}
+	return true, "fallback"
+}
+
+func firstMatch(patterns []rulePattern, content []byte) (rulePattern, bool) {
+	for _, p := range patterns {
+		if p.re.Match(content) {
+			return p, true
+		}
+	}
+	return rulePattern{}, false
+}
+
+// abbreviate shortens a pattern for display, keeping its first line and at most 60 bytes of it
+func abbreviate(source string) string {
+	line, _, multiline := strings.Cut(source, "\n")
+	if len(line) > 60 {
+		return "/" + line[:60] + "…/"
+	}
+	if multiline {
+		return "/" + line + "…/"
+	}
+	return "/" + line + "/"
+}
+
+func (d *Detector) byHeuristicsStrategy(path string, content, _ []byte, candidates []Candidate) []Candidate {
+	if len(content) > heuristicsConsiderBytes {
+		content = content[:heuristicsConsiderBytes]
+	}
+	for _, dis := range d.heuristics.disambiguations {
+		if !dis.matches(path) {
+			continue
+		}
+		// the first disambiguation covering the file decides and its first matching rule wins, as in Linguist
+		for _, rule := range dis.rules {
+			if rule.skipped {
+				// the skipped rule might have matched, so no later rule may decide
+				return nil
+			}
+			if ok, why := rule.explain(content); ok {
+				reason := fmt.Sprintf("rule %d for %s: %s", rule.position, strings.Join(dis.extensions, ", "), why)
+				return d.candidates(rule.languages, candidates, StrategyHeuristics, reason)
+			}
+		}
+		return nil
+	}
+	return nil
+}
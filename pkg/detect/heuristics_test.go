@@ -0,0 +1,77 @@
+package detect
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// heuristicsTestData has one .pl disambiguation: a Prolog rule, a Perl rule RE2 cannot express and a Raku fallback
+func heuristicsTestData() *Data {
+	return &Data{
+		Languages: []db.Language{
+			{LanguageID: 1, Name: "Perl", Extensions: []string{".pl"}},
+			{LanguageID: 2, Name: "Prolog", Extensions: []string{".pl"}},
+			{LanguageID: 3, Name: "Raku", Extensions: []string{".pl"}},
+		},
+		Disambiguations: []db.HeuristicDisambiguation{{ID: 1, Extensions: []string{".pl"}}},
+		HeuristicRules: []db.HeuristicRule{
+			{ID: 1, DisambiguationID: 1, Position: 0, Languages: []string{"Prolog"}, Pattern: []string{`^[^#]*:-`}},
+			{ID: 2, DisambiguationID: 1, Position: 1, Languages: []string{"Perl"}, Pattern: []string{`\buse\s+strict(?=;)`}},
+			{ID: 3, DisambiguationID: 1, Position: 2, Languages: []string{"Raku"}},
+		},
+	}
+}
+
+func TestHeuristics(t *testing.T) {
+	d := NewFromData(heuristicsTestData())
+	skipped := d.SkippedHeuristics()
+	if len(skipped) != 1 || skipped[0].Position != 1 {
+		t.Fatalf("SkippedHeuristics() = %v, want rule 1 only", skipped)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"rule before the skipped one decides", "foo :- bar.\n", []string{"Prolog"}},
+		// the skipped rule might have matched, so the fallback after it must not claim the file
+		{"skipped rule leaves the file undecided", "use strict;\n", []string{"Perl", "Prolog", "Raku"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := d.Detect("main.pl", []byte(tt.content))
+			var got []string
+			for _, c := range result.Candidates {
+				got = append(got, c.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Detect() candidates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicsAndRule(t *testing.T) {
+	data := heuristicsTestData()
+	data.HeuristicRules = []db.HeuristicRule{
+		{ID: 1, DisambiguationID: 1, Position: 0, Languages: []string{"Perl"}},
+		{ID: 2, DisambiguationID: 1, ParentRuleID: pgtype.Int4{Int32: 1, Valid: true}, Position: 0, Pattern: []string{`\buse\b`}},
+		{ID: 3, DisambiguationID: 1, ParentRuleID: pgtype.Int4{Int32: 1, Valid: true}, Position: 1, NegativePattern: []string{`:-`}},
+		{ID: 4, DisambiguationID: 1, Position: 1, Languages: []string{"Prolog"}},
+	}
+	d := NewFromData(data)
+	for content, want := range map[string]string{
+		"use strict;\n":       "Perl",
+		"use x.\nfoo :- y.\n": "Prolog",
+		"foo.\n":              "Prolog",
+	} {
+		lang, ok := d.Detect("main.pl", []byte(content)).Language()
+		if !ok || lang.Name != want {
+			t.Errorf("Detect(%q) = %q, want %q", content, lang.Name, want)
+		}
+	}
+}
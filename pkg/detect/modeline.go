@@ -0,0 +1,84 @@
+package detect
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// modelineSearchLines is how many lines at the head and at the tail of a file are searched for a modeline,
+// the same scope Linguist and Vim's default modelines setting use
+const modelineSearchLines = 5
+
+var (
+	// emacsModeline matches the -*- ... -*- block, mode: perl or the short form -*- perl -*- inside it
+	emacsModeline = regexp.MustCompile(`-\*-(.*?)-\*-`)
+	emacsMode     = regexp.MustCompile(`(?i)(?:^|[;\s])mode\s*:\s*([^:;\s]+)`)
+	// vimModeline matches vim: set ft=ruby:, vim600: syntax=perl, ex: filetype=sh and their variations,
+	// the option list may start right after the colon as in vim:ft=ruby
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|Vim|ex)(?:[<=>]?\d+)?:\s*(?:.*?[\s:])?(?:filetype|ft|syntax)\s*=\s*(\w+)`)
+)
+
+func (d *Detector) byModelineStrategy(_ string, content, tail []byte, candidates []Candidate) []Candidate {
+	mode, reason := modeline(modelineScope(content, tail))
+	if mode == "" {
+		return nil
+	}
+	id, ok := d.byAlias[strings.ToLower(mode)]
+	if !ok {
+		return nil
+	}
+	return d.candidates([]int32{id}, candidates, StrategyModeline, reason)
+}
+
+// Modeline returns the mode named by a Vim or Emacs modeline in the head or tail of content, and the modeline
+// itself trimmed for display. It returns empty strings when there is none.
+func Modeline(content []byte) (string, string) {
+	return modeline(modelineScope(content, nil))
+}
+
+func modeline(scope [][]byte) (string, string) {
+	for _, line := range scope {
+		if m := emacsModeline.FindSubmatch(line); m != nil {
+			if mode := emacsModeName(string(m[1])); mode != "" {
+				return mode, string(m[0])
+			}
+		}
+		if m := vimModeline.FindSubmatch(line); m != nil {
+			return string(m[1]), strings.TrimSpace(string(m[0]))
+		}
+	}
+	return "", ""
+}
+
+// emacsModeName reads the mode out of the text between the -*- delimiters
+func emacsModeName(inner string) string {
+	if m := emacsMode.FindStringSubmatch(inner); m != nil {
+		return m[1]
+	}
+	// -*- perl -*- names the mode on its own, anything else is a list of variables without one
+	fields := strings.Fields(inner)
+	if len(fields) == 1 && !strings.ContainsAny(fields[0], ":;") {
+		return fields[0]
+	}
+	return ""
+}
+
+// modelineScope returns the lines searched for a modeline, head first. With a tail, the head lines come from
+// content and the tail lines from tail.
+func modelineScope(content, tail []byte) [][]byte {
+	lines := bytes.Split(bytes.TrimRight(content, "\r\n"), []byte("\n"))
+	if tail == nil {
+		if len(lines) <= 2*modelineSearchLines {
+			return lines
+		}
+		return append(lines[:modelineSearchLines:modelineSearchLines], lines[len(lines)-modelineSearchLines:]...)
+	}
+	// tail may start in the middle of a line, so its first line is never searched
+	tailLines := bytes.Split(bytes.TrimRight(tail, "\r\n"), []byte("\n"))[1:]
+	if len(tailLines) > modelineSearchLines {
+		tailLines = tailLines[len(tailLines)-modelineSearchLines:]
+	}
+	head := lines[:min(len(lines), modelineSearchLines):min(len(lines), modelineSearchLines)]
+	return append(head, tailLines...)
+}
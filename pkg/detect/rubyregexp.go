@@ -0,0 +1,278 @@
+package detect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TranslateRubyRegexp rewrites a Ruby (Onigmo) regular expression from Linguist into RE2 syntax.
+// ^ and $ always match at line breaks in Ruby, so the result runs in multi-line mode, and Ruby's m flag,
+// which lets . match a newline, becomes RE2's s flag. Extended mode is stripped, atomic groups and possessive
+// quantifiers lose their backtracking guarantees, which only changes performance. Lookarounds,
+// backreferences and subexpression calls have no RE2 equivalent and fail the translation.
+func TranslateRubyRegexp(pattern string) (string, error) {
+	out := []byte("(?m)")
+	inClass := false
+	// extended is the x flag in effect, groups restores the flag of the enclosing group when a group closes
+	extended := false
+	type group struct {
+		extended bool
+		start    int
+	}
+	var groups []group
+	// atom is where the last atom starts in out, so a quantifier following it can be wrapped
+	atom := -1
+	// afterQuantifier is the kind of the quantifier just written, a quantifier following it is possessive, lazy
+	// or repeats it
+	afterQuantifier := quantifierNone
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		quantifier := quantifierNone
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			escaped, err := translateRubyEscape(pattern[i], inClass)
+			if err != nil {
+				return "", err
+			}
+			if !inClass {
+				atom = len(out)
+			}
+			out = append(out, escaped...)
+		case inClass:
+			switch {
+			case strings.HasPrefix(pattern[i:], "[:"):
+				end := strings.Index(pattern[i:], ":]")
+				if end < 0 {
+					return "", fmt.Errorf("unterminated POSIX class")
+				}
+				out = append(out, pattern[i:i+end+2]...)
+				i += end + 1
+			case c == '[':
+				return "", fmt.Errorf("nested character classes are not supported")
+			case strings.HasPrefix(pattern[i:], "&&"):
+				return "", fmt.Errorf("character class intersection is not supported")
+			default:
+				if c == ']' {
+					inClass = false
+				}
+				out = append(out, c)
+			}
+		case extended && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'):
+			quantifier = afterQuantifier
+		case extended && c == '#':
+			for i+1 < len(pattern) && pattern[i+1] != '\n' {
+				i++
+			}
+			quantifier = afterQuantifier
+		case strings.HasPrefix(pattern[i:], `[\s&&[^\r\n]]`), strings.HasPrefix(pattern[i:], `[\s&&[^\n]]`):
+			// horizontal whitespace, the one class intersection Linguist's heuristics rely on
+			atom = len(out)
+			if strings.HasPrefix(pattern[i:], `[\s&&[^\r\n]]`) {
+				out = append(out, `[\t\v\f ]`...)
+			} else {
+				out = append(out, `[\t\v\f\r ]`...)
+			}
+			i += strings.Index(pattern[i:], "]]") + 1
+		case c == '[':
+			inClass = true
+			atom = len(out)
+			out = append(out, c)
+			// a ] right after [ or [^ is a literal
+			if strings.HasPrefix(pattern[i+1:], "^]") {
+				out = append(out, "^]"...)
+				i += 2
+			} else if strings.HasPrefix(pattern[i+1:], "]") {
+				out = append(out, ']')
+				i++
+			}
+		case c == '(' && strings.HasPrefix(pattern[i+1:], "?"):
+			rest := pattern[i+2:]
+			switch {
+			case strings.HasPrefix(rest, "="), strings.HasPrefix(rest, "!"), strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, "<!"):
+				return "", fmt.Errorf("lookaround assertions are not supported")
+			case strings.HasPrefix(rest, ">"):
+				groups = append(groups, group{extended: extended, start: len(out)})
+				out = append(out, "(?:"...)
+				i += 2
+			case strings.HasPrefix(rest, "#"):
+				end := strings.IndexByte(rest, ')')
+				if end < 0 {
+					return "", fmt.Errorf("unterminated comment group")
+				}
+				i += 2 + end
+				quantifier = afterQuantifier
+			case strings.HasPrefix(rest, "<"), strings.HasPrefix(rest, "'"):
+				end := strings.IndexAny(rest[1:], ">'")
+				if end < 0 {
+					return "", fmt.Errorf("unterminated group name")
+				}
+				groups = append(groups, group{extended: extended, start: len(out)})
+				out = append(out, "(?P<"+rest[1:1+end]+">"...)
+				i += 2 + 1 + end
+			default:
+				flags, n, x, err := translateRubyFlags(rest)
+				if err != nil {
+					return "", err
+				}
+				if rest[n-1] == ':' {
+					// the flags only apply inside the group
+					groups = append(groups, group{extended: extended, start: len(out)})
+				}
+				if x != nil {
+					extended = *x
+				}
+				out = append(out, flags...)
+				i += 1 + n
+			}
+		case c == '(':
+			groups = append(groups, group{extended: extended, start: len(out)})
+			out = append(out, c)
+		case c == ')':
+			if len(groups) > 0 {
+				g := groups[len(groups)-1]
+				groups = groups[:len(groups)-1]
+				extended = g.extended
+				atom = g.start
+			}
+			out = append(out, c)
+		case c == '{' && rubyInterval(pattern[i:]) > 0:
+			n := rubyInterval(pattern[i:])
+			interval := pattern[i : i+n]
+			i += n - 1
+			if afterQuantifier != quantifierNone {
+				// a quantified quantifier is an error in RE2, Onigmo repeats the quantified expression
+				if atom < 0 {
+					return "", fmt.Errorf("interval %s repeats nothing", interval)
+				}
+				out = append(out[:atom], append([]byte("(?:"), append(out[atom:], ')')...)...)
+			}
+			if strings.HasPrefix(interval, "{,") {
+				// Onigmo's {,m} is {0,m}, RE2 would read it as a literal
+				interval = "{0" + interval[1:]
+			}
+			quantifier = quantifierRange
+			if !strings.Contains(interval, ",") {
+				quantifier = quantifierExact
+			}
+			out = append(out, interval...)
+		case c == '*' || c == '+' || c == '?':
+			switch {
+			case afterQuantifier == quantifierExact || afterQuantifier == quantifierRange && c != '?':
+				// Onigmo only knows possessive * + ?, so x{2}+ is (?:x{2})+, and only a range is made lazy by ?
+				if atom < 0 {
+					return "", fmt.Errorf("%c repeats nothing", c)
+				}
+				out = append(out[:atom], append([]byte("(?:"), append(out[atom:], ')', c)...)...)
+				quantifier = quantifierSimple
+			case afterQuantifier != quantifierNone:
+				// a possessive + is dropped, a lazy ? is kept
+				if c == '?' {
+					out = append(out, c)
+				}
+			default:
+				quantifier = quantifierSimple
+				out = append(out, c)
+			}
+		default:
+			atom = len(out)
+			out = append(out, c)
+		}
+		afterQuantifier = quantifier
+	}
+	if inClass {
+		return "", fmt.Errorf("unterminated character class")
+	}
+	return string(out), nil
+}
+
+// Quantifier kinds tracked by TranslateRubyRegexp
+const (
+	quantifierNone = iota
+	// quantifierSimple is *, + or ?
+	quantifierSimple
+	// quantifierExact is {n}
+	quantifierExact
+	// quantifierRange is {n,}, {,m} or {n,m}
+	quantifierRange
+)
+
+// rubyInterval returns the length of the {n}, {n,}, {,m} or {n,m} interval s starts with, or zero when the
+// brace is a literal
+func rubyInterval(s string) int {
+	digits := 0
+	comma := false
+	for n := 1; n < len(s); n++ {
+		switch c := s[n]; {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == ',' && !comma:
+			comma = true
+		case c == '}' && digits > 0:
+			return n + 1
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// translateRubyEscape returns the RE2 form of the escape \c
+func translateRubyEscape(c byte, inClass bool) (string, error) {
+	switch c {
+	case 'h':
+		if inClass {
+			return "0-9a-fA-F", nil
+		}
+		return "[0-9a-fA-F]", nil
+	case 'H':
+		if inClass {
+			return "", fmt.Errorf(`\H inside a character class is not supported`)
+		}
+		return "[^0-9a-fA-F]", nil
+	case 'Z':
+		return `(?:\n?\z)`, nil
+	case 'e':
+		return `\x1b`, nil
+	case 'G', 'K', 'R', 'X', 'k', 'g':
+		return "", fmt.Errorf(`\%c is not supported`, c)
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return "", fmt.Errorf("backreferences are not supported")
+	}
+	return `\` + string(c), nil
+}
+
+// translateRubyFlags reads the flags of a (?imx-imx) or (?imx-imx: group from rest, which starts after (?.
+// It returns the RE2 group opening, how many bytes of rest it consumed and the extended mode the flags set,
+// nil when they leave it alone.
+func translateRubyFlags(rest string) (string, int, *bool, error) {
+	var flags strings.Builder
+	var extended *bool
+	negated := false
+	for n := 0; n < len(rest); n++ {
+		switch c := rest[n]; c {
+		case 'i':
+			flags.WriteByte('i')
+		case 'm':
+			flags.WriteByte('s')
+		case 'x':
+			on := !negated
+			extended = &on
+		case '-':
+			negated = true
+			flags.WriteByte('-')
+		case ')', ':':
+			f := strings.TrimSuffix(flags.String(), "-")
+			switch {
+			case c == ':' && f == "":
+				return "(?:", n + 1, extended, nil
+			case f == "":
+				return "", n + 1, extended, nil
+			}
+			return "(?" + f + string(c), n + 1, extended, nil
+		default:
+			return "", 0, nil, fmt.Errorf("unsupported group (?%c", c)
+		}
+	}
+	return "", 0, nil, fmt.Errorf("unterminated group")
+}
@@ -0,0 +1,113 @@
+package detect
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTranslateRubyRegexp(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+	}{
+		{"plain", `^import\s+\w+`, `(?m)^import\s+\w+`},
+		{"dot all flag", `(?m:a.b)`, `(?m)(?s:a.b)`},
+		{"atomic group", `(?>ab)c`, `(?m)(?:ab)c`},
+		{"named group", `(?<name>\w+)`, `(?m)(?P<name>\w+)`},
+		{"comment group", `a(?#note)b`, `(?m)ab`},
+		{"hex escape", `\h+\H`, `(?m)[0-9a-fA-F]+[^0-9a-fA-F]`},
+		{"hex escape in class", `[\h_]`, `(?m)[0-9a-fA-F_]`},
+		{"horizontal whitespace", `a[\s&&[^\r\n]]b`, `(?m)a[\t\v\f ]b`},
+		{"leading bracket in class", `[]a]`, `(?m)[]a]`},
+		{"extended mode", `(?x) a b # comment` + "\n" + `c`, `(?m)abc`},
+		{"extended mode keeps escaped and class spaces", `(?x) a\ b [ ]`, `(?m)a\ b[ ]`},
+		{"extended mode ends with its group", `(?x: a b ) c d`, `(?m)(?:ab) c d`},
+		{"extended mode ends with the enclosing group", `( (?x) a ) b`, `(?m)( a) b`},
+		{"extended mode turned off", `(?x)a (?-x)b c`, `(?m)ab c`},
+		{"extended mode turned off in a group", `(?x)a(?-x: b )c d`, `(?m)a(?: b )cd`},
+		{"possessive", `a++b*+c?+`, `(?m)a+b*c?`},
+		{"lazy", `a+?b*?`, `(?m)a+?b*?`},
+		{"interval", `a{2}b{2,}c{2,3}`, `(?m)a{2}b{2,}c{2,3}`},
+		{"interval without minimum", `a{,3}`, `(?m)a{0,3}`},
+		{"interval repeated by +", `x{2}+`, `(?m)(?:x{2})+`},
+		{"range repeated by +", `[a-z]{2,3}+`, `(?m)(?:[a-z]{2,3})+`},
+		{"group interval repeated by +", `(ab){2}+c`, `(?m)(?:(ab){2})+c`},
+		{"exact interval made optional", `a{2}?`, `(?m)(?:a{2})?`},
+		{"lazy range", `a{2,3}?`, `(?m)a{2,3}?`},
+		{"interval repeated across extended whitespace", `(?x) a{2} + b`, `(?m)(?:a{2})+b`},
+		{"repeated quantifier", `a*{2}`, `(?m)(?:a*){2}`},
+		{"literal braces", `a{b}`, `(?m)a{b}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TranslateRubyRegexp(tt.pattern)
+			if err != nil {
+				t.Fatalf("TranslateRubyRegexp(%q) failed: %v", tt.pattern, err)
+			}
+			if got != tt.want {
+				t.Errorf("TranslateRubyRegexp(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+			if _, err := regexp.Compile(got); err != nil {
+				t.Errorf("translation %q does not compile: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestTranslateRubyRegexpMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		// Onigmo repeats x{2} as a whole, so only even runs of x match
+		{`^x{2}+$`, []string{"xx", "xxxx"}, []string{"x", "xxx"}},
+		{`^(?x: a b ) c d$`, []string{"ab c d"}, []string{"abcd", "a b c d"}},
+		{`^(?x)a (?-x)b c$`, []string{"ab c"}, []string{"abc"}},
+		// ^ and $ match at every line in Ruby
+		{`^two$`, []string{"one\ntwo", "two\nthree"}, []string{"one two"}},
+	}
+	for _, tt := range tests {
+		translated, err := TranslateRubyRegexp(tt.pattern)
+		if err != nil {
+			t.Fatalf("TranslateRubyRegexp(%q) failed: %v", tt.pattern, err)
+		}
+		re := regexp.MustCompile(translated)
+		for _, s := range tt.match {
+			if !re.MatchString(s) {
+				t.Errorf("%q translated to %q does not match %q", tt.pattern, translated, s)
+			}
+		}
+		for _, s := range tt.noMatch {
+			if re.MatchString(s) {
+				t.Errorf("%q translated to %q matches %q", tt.pattern, translated, s)
+			}
+		}
+	}
+}
+
+func TestTranslateRubyRegexpUnsupported(t *testing.T) {
+	tests := []struct {
+		pattern string
+		err     string
+	}{
+		{`a(?=b)`, "lookaround"},
+		{`a(?!b)`, "lookaround"},
+		{`(?<=a)b`, "lookaround"},
+		{`(?<!a)b`, "lookaround"},
+		{`(a)\1`, "backreferences"},
+		{`(?<v>a)\g<v>`, `\g`},
+		{`[a-z&&[^q]]`, "intersection"},
+		{`[a[b]]`, "nested"},
+		{`[a-z`, "unterminated character class"},
+		{`{2}+`, "repeats nothing"},
+	}
+	for _, tt := range tests {
+		_, err := TranslateRubyRegexp(tt.pattern)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("TranslateRubyRegexp(%q) error = %v, want one containing %q", tt.pattern, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package detect
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// interpreterVersion matches a trailing version such as the .12 of python3.12 or the 3 of python3
+	interpreterVersion = regexp.MustCompile(`(\.\d+|\d+)$`)
+	// execHack matches the sh preamble that re-executes the script with another interpreter, exec tclsh "$0" "$@"
+	execHack = regexp.MustCompile(`exec (\w+)[\s'"]+\$0[\s'"]+\$@`)
+)
+
+// execHackLines is how many lines of an sh script are searched for an exec preamble, the same limit Linguist uses
+const execHackLines = 5
+
+func (d *Detector) byShebangStrategy(_ string, content, _ []byte, candidates []Candidate) []Candidate {
+	interpreter := Interpreter(content)
+	if interpreter == "" {
+		return nil
+	}
+	// python3.12 is listed as neither python3.12 nor python3, drop version parts until the name is known
+	for name := interpreter; name != ""; name = interpreterVersion.ReplaceAllString(name, "") {
+		if ids, ok := d.byInterpreter[name]; ok {
+			return d.candidates(ids, candidates, StrategyShebang, name)
+		}
+		if !interpreterVersion.MatchString(name) {
+			break
+		}
+	}
+	return nil
+}
+
+// Interpreter returns the interpreter named by the #! line of content, or an empty string when there is none.
+// It looks through env along with its options and variable assignments, so
+// #!/usr/bin/env -S FOO=1 python3 -u yields python3, and follows the exec preamble of sh scripts.
+func Interpreter(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bytes.Cut(content[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = envCommand(fields[1:])
+		if interpreter == "" {
+			return ""
+		}
+		interpreter = filepath.Base(interpreter)
+	}
+	if interpreter == "sh" {
+		lines := bytes.SplitN(content, []byte("\n"), execHackLines+1)
+		for _, l := range lines[:min(len(lines), execHackLines)] {
+			if m := execHack.FindSubmatch(l); m != nil {
+				return string(m[1])
+			}
+		}
+	}
+	return interpreter
+}
+
+// envCommand returns the command env(1) would run given args, skipping options and NAME=value assignments.
+// The kernel passes everything after env as one argument, which -S splits, so -S "python3 -u" arrives here split already.
+func envCommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+			return ""
+		case arg == "-u" || arg == "--unset" || arg == "-C" || arg == "--chdir":
+			// these take the next argument as their value
+			i++
+		case strings.HasPrefix(arg, "-S") && len(arg) > 2:
+			// -Spython3 with the command glued to the option
+			return arg[2:]
+		case strings.HasPrefix(arg, "-"):
+		case strings.Contains(arg, "="):
+		default:
+			return arg
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package detect
+
+import (
+	"regexp"
+)
+
+// tokenizeBytes is how much of a file Tokenize reads, the same limit Linguist's tokenizer uses
+const tokenizeBytes = 100 * 1024
+
+var (
+	// literals and comments carry little about the language and a lot of noise, they are dropped before
+	// anything is extracted. The order matters, a quote inside a comment must not start a string.
+	blockComment = regexp.MustCompile(`(?s)/\*.*?\*/|<!--.*?-->|\{-.*?-\}|\(\*.*?\*\)|""".*?"""|'''.*?'''`)
+	skipped      = []*regexp.Regexp{
+		regexp.MustCompile(`(?s)"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*'`),
+		regexp.MustCompile(`(?m)(?://|--|#|%|")\s.*$`),
+		regexp.MustCompile(`\b(?:0x[0-9A-Fa-f][0-9A-Fa-f.]*|\d[\d.]*)(?:[uU][lL]{0,2}|(?:[eE][-+]\d*)?[fFlL]*)`),
+	}
+	sgmlTag       = regexp.MustCompile(`(?s)(</?[^\s<>=\d"']+)(?:\s(.*?)/?>|>)`)
+	sgmlAttribute = regexp.MustCompile(`(\w+)=(?:"[^"]*"|'[^']*'|[^\s>]*)|(\w+)`)
+	punctuation   = regexp.MustCompile(`[;{}()\[\]]`)
+	regularToken  = regexp.MustCompile(`[\w.@#/*]+`)
+	operator      = regexp.MustCompile(`<<?|\+|-|\*|/|%|&&?|\|\|?`)
+)
+
+// Tokenize splits content into the tokens the classifier is trained on, in the spirit of Linguist's tokenizer:
+// the interpreter of a #! line, SGML tags and attribute names, punctuation, identifiers and operators.
+// String and number literals and comments are skipped. Block comments go first so <!-- --> is not read as a tag.
+func Tokenize(content []byte) []string {
+	if len(content) > tokenizeBytes {
+		content = content[:tokenizeBytes]
+	}
+	var tokens []string
+	if interpreter := Interpreter(content); interpreter != "" {
+		tokens = append(tokens, "SHEBANG#!"+interpreter)
+		content = content[min(len(content), lineEnd(content)):]
+	}
+	content = blockComment.ReplaceAll(content, []byte(" "))
+	content = sgmlTag.ReplaceAllFunc(content, func(tag []byte) []byte {
+		m := sgmlTag.FindSubmatch(tag)
+		tokens = append(tokens, string(m[1])+">")
+		for _, attr := range sgmlAttribute.FindAllSubmatch(m[2], -1) {
+			if attr[1] != nil {
+				tokens = append(tokens, string(attr[1])+"=")
+			} else {
+				tokens = append(tokens, string(attr[2]))
+			}
+		}
+		return []byte(" ")
+	})
+	for _, re := range skipped {
+		content = re.ReplaceAll(content, []byte(" "))
+	}
+	for _, re := range []*regexp.Regexp{punctuation, regularToken, operator} {
+		content = re.ReplaceAllFunc(content, func(token []byte) []byte {
+			tokens = append(tokens, string(token))
+			return []byte(" ")
+		})
+	}
+	return tokens
+}
+
+// lineEnd returns the index just past the first line of content
+func lineEnd(content []byte) int {
+	for i, b := range content {
+		if b == '\n' {
+			return i + 1
+		}
+	}
+	return len(content)
+}
@@ -0,0 +1,159 @@
+package resync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// Sync outcomes reported through Status
+const (
+	OutcomeSynced = "synced"
+	// OutcomeSkipped means another replica held the sync lock, so this one left the table alone
+	OutcomeSkipped = "skipped"
+	OutcomeFailed  = "failed"
+)
+
+// Status is the state of the scheduler as exposed by the admin endpoint
+type Status struct {
+	Enabled     bool       `json:"enabled"`
+	Interval    string     `json:"interval,omitempty"`
+	Running     bool       `json:"running"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+	Outcome     string     `json:"outcome,omitempty"`
+	// Error only says the sync failed, the error itself can carry URLs and database details and is only logged
+	Error   string `json:"error,omitempty"`
+	RunID   int32  `json:"run_id,omitempty"`
+	Added   int    `json:"added"`
+	Changed int    `json:"changed"`
+	Removed int    `json:"removed"`
+}
+
+// syncFailedMessage is the Status.Error of a failed sync, the endpoint serving Status is unauthenticated
+const syncFailedMessage = "language sync failed, see the server log for details"
+
+// Scheduler periodically re-runs the Linguist ingest of seer migrate while the server is up.
+// Replicas coordinate through a Postgres advisory lock, only the one holding it syncs.
+type Scheduler struct {
+	pool     *pgxpool.Pool
+	interval time.Duration
+	opts     db.LinguistOptions
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// NewScheduler returns a scheduler syncing every interval, an interval of zero returns a disabled scheduler
+// that only reports itself as such
+func NewScheduler(pool *pgxpool.Pool, interval time.Duration, opts db.LinguistOptions) *Scheduler {
+	s := &Scheduler{pool: pool, interval: interval, opts: opts}
+	s.status.Enabled = interval > 0
+	if s.status.Enabled {
+		s.status.Interval = interval.String()
+	}
+	return s
+}
+
+// Status returns a copy of the current scheduler status
+func (s *Scheduler) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Run syncs once immediately and then every interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.SyncOnce(ctx)
+		next := time.Now().Add(s.interval)
+		s.mu.Lock()
+		s.status.NextAttempt = &next
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce runs a single sync if no other replica is syncing, and records the outcome
+func (s *Scheduler) SyncOnce(ctx context.Context) {
+	// a sync must not overlap the next tick
+	ctx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+
+	started := time.Now()
+	s.mu.Lock()
+	s.status.Running = true
+	s.status.LastAttempt = &started
+	s.mu.Unlock()
+
+	summary, locked, err := s.sync(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Running = false
+	s.status.Error = ""
+	switch {
+	case err != nil:
+		s.status.Outcome = OutcomeFailed
+		s.status.Error = syncFailedMessage
+		log.Error().Err(err).Msg("scheduled language sync failed")
+	case !locked:
+		s.status.Outcome = OutcomeSkipped
+		log.Info().Msg("another replica is syncing languages, skipped scheduled sync")
+	default:
+		finished := time.Now()
+		s.status.Outcome = OutcomeSynced
+		s.status.LastSuccess = &finished
+		s.status.RunID = summary.RunID
+		s.status.Added = len(summary.Added)
+		s.status.Changed = len(summary.Changed)
+		s.status.Removed = len(summary.Removed)
+		log.Info().
+			Int32("run", summary.RunID).
+			Int("added", s.status.Added).
+			Int("changed", s.status.Changed).
+			Int("removed", s.status.Removed).
+			Msg("scheduled language sync finished")
+	}
+}
+
+func (s *Scheduler) sync(ctx context.Context) (*db.LanguageSyncSummary, bool, error) {
+	// the advisory lock is held by a session, so the whole sync runs on one pooled connection
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+	var summary *db.LanguageSyncSummary
+	locked, err := db.TryWithAdvisoryLock(ctx, conn, db.LanguageSyncLockKey, func() error {
+		ingested, err := db.IngestLinguist(ctx, conn, s.opts)
+		if err != nil {
+			return err
+		}
+		summary = ingested.Languages
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, locked, fmt.Errorf("sync took longer than the %s interval: %w", s.interval, err)
+		}
+		return nil, locked, err
+	}
+	return summary, locked, nil
+}
@@ -2,12 +2,15 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"strconv"
 
 	"github.com/caner-cetin/seer/internal"
+	"github.com/caner-cetin/seer/internal/config"
 	"github.com/caner-cetin/seer/pkg/db"
+	"github.com/caner-cetin/seer/pkg/resync"
 	"github.com/caner-cetin/seer/pkg/server/endpoints"
 
 	"github.com/rs/zerolog/log"
@@ -33,6 +36,19 @@ func GetRunCmd() *cobra.Command {
 	return runCmd
 }
 
+// migrate migrates the schema under the language sync lock, so a server starting while seer migrate runs waits for it
+func migrate(ctx context.Context, app internal.AppCtx) error {
+	// the advisory lock is held by a session, so it is taken on one pooled connection for the whole migration
+	conn, err := app.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+	return db.WithAdvisoryLock(ctx, conn, db.LanguageSyncLockKey, func() error {
+		return db.Migrate(app.StdDB)
+	})
+}
+
 func runServer(command *cobra.Command, args []string) {
 	r := chi.NewRouter()
 
@@ -40,18 +56,29 @@ func runServer(command *cobra.Command, args []string) {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	config.Config.SetDBURL()
 	app := internal.AppCtx{}
-	if err := app.InitializeDB(); err != nil {
+	if err := app.InitializePool(); err != nil {
 		log.Error().Err(err).Msg("failed to initialize database")
 		return
 	}
 	defer app.Cleanup()
-	if err := db.Migrate(app.StdDB); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := migrate(ctx, app); err != nil {
 		log.Error().Err(err).Msg("failed to migrate database")
 		return
 	}
 	r.Use(WithAppContext(app))
 
+	linguist := config.Config.Linguist
+	sources := db.DefaultLinguistOptions()
+	sources.LanguagesPath = linguist.Languages()
+	sources.Overlays = linguist.Overlays
+	sources.Fetch = linguist.FetchOptions()
+	scheduler := resync.NewScheduler(app.Pool, linguist.SyncEvery(), sources)
+	go scheduler.Run(ctx)
+
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://cansu.dev", "http://localhost:5173", "https://dj.cansu.dev"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
@@ -61,6 +88,10 @@ func runServer(command *cobra.Command, args []string) {
 	}))
 
 	r.Get("/health", endpoints.Health)
+	r.Get("/languages/grammars", endpoints.LanguageGrammars)
+	r.Route("/admin", func(r chi.Router) {
+		r.Get("/sync", endpoints.LanguageSyncStatus(scheduler))
+	})
 	if port == 0 {
 		addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 		if err != nil {
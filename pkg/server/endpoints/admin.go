@@ -0,0 +1,19 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/caner-cetin/seer/pkg/resync"
+	"github.com/rs/zerolog/log"
+)
+
+// LanguageSyncStatus reports when the background language sync last ran and how it went
+func LanguageSyncStatus(scheduler *resync.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(scheduler.Status()); err != nil {
+			log.Error().Err(err).Msg("failed to encode language sync status")
+		}
+	}
+}
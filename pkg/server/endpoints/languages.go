@@ -0,0 +1,43 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/caner-cetin/seer/internal"
+	"github.com/rs/zerolog/log"
+)
+
+// languageGrammar is a language with the scope it is highlighted with and the grammar repository providing that scope
+type languageGrammar struct {
+	LanguageID int32   `json:"language_id"`
+	Name       string  `json:"name"`
+	TmScope    *string `json:"tm_scope"`
+	Repository *string `json:"repository"`
+}
+
+// LanguageGrammars lists the grammar backing each language, repository is null when no grammar provides the scope
+func LanguageGrammars(w http.ResponseWriter, r *http.Request) {
+	app := r.Context().Value(internal.APP_CONTEXT_KEY).(internal.AppCtx)
+	rows, err := app.DB.GetLanguageGrammars(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get language grammars")
+		http.Error(w, "failed to get language grammars", http.StatusInternalServerError)
+		return
+	}
+	grammars := make([]languageGrammar, 0, len(rows))
+	for _, row := range rows {
+		grammar := languageGrammar{LanguageID: row.LanguageID, Name: row.Name}
+		if row.TmScope.Valid {
+			grammar.TmScope = &row.TmScope.String
+		}
+		if row.Repository.Valid {
+			grammar.Repository = &row.Repository.String
+		}
+		grammars = append(grammars, grammar)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(grammars); err != nil {
+		log.Error().Err(err).Msg("failed to encode language grammars")
+	}
+}
@@ -2,6 +2,429 @@
 SELECT *
 FROM languages;
 
--- name: GetLanguageCount :one
-SELECT COUNT(id)
-FROM languages;
\ No newline at end of file
+-- name: DeleteLanguages :exec
+DELETE FROM languages
+WHERE language_id = ANY(@language_ids::int []);
+
+-- name: CreateIngestRun :one
+INSERT INTO ingest_runs (source, sha256, etag)
+VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: FinishIngestRun :exec
+UPDATE ingest_runs
+SET added_count = $2,
+  changed_count = $3,
+  removed_count = $4,
+  total_count = $5,
+  finished_at = clock_timestamp()
+WHERE id = $1;
+
+-- name: GetIngestRun :one
+SELECT *
+FROM ingest_runs
+WHERE id = $1;
+
+-- name: ListIngestRuns :many
+SELECT *
+FROM ingest_runs
+ORDER BY id DESC;
+
+-- name: SnapshotLanguages :exec
+INSERT INTO language_snapshots (
+    ingest_run_id,
+    name,
+    fs_name,
+    "type",
+    aliases,
+    ace_mode,
+    codemirror_mode,
+    codemirror_mime_type,
+    wrap,
+    extensions,
+    filenames,
+    interpreters,
+    language_id,
+    color,
+    tm_scope,
+    "group",
+    custom,
+    overlay
+  )
+SELECT @ingest_run_id::int,
+  name,
+  fs_name,
+  "type",
+  aliases,
+  ace_mode,
+  codemirror_mode,
+  codemirror_mime_type,
+  wrap,
+  extensions,
+  filenames,
+  interpreters,
+  language_id,
+  color,
+  tm_scope,
+  "group",
+  custom,
+  overlay
+FROM languages;
+
+-- name: GetLanguageSnapshot :many
+SELECT *
+FROM language_snapshots
+WHERE ingest_run_id = $1;
+
+-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_lock(@key::bigint);
+
+-- name: AdvisoryLock :exec
+SELECT pg_advisory_lock(@key::bigint);
+
+-- name: AdvisoryUnlock :one
+SELECT pg_advisory_unlock(@key::bigint);
+
+-- name: DeleteLanguageLookups :exec
+DELETE FROM language_lookups;
+
+-- name: InsertLanguageLookups :exec
+INSERT INTO language_lookups (language_id, kind, value, position)
+SELECT l.language_id, 'extension'::language_lookup_kind, e.value, e.position
+FROM languages l,
+  unnest(l.extensions) WITH ORDINALITY AS e(value, position)
+UNION ALL
+SELECT l.language_id, 'filename'::language_lookup_kind, f.value, f.position
+FROM languages l,
+  unnest(l.filenames) WITH ORDINALITY AS f(value, position)
+UNION ALL
+SELECT l.language_id, 'interpreter'::language_lookup_kind, i.value, i.position
+FROM languages l,
+  unnest(l.interpreters) WITH ORDINALITY AS i(value, position)
+UNION ALL
+SELECT l.language_id, 'alias'::language_lookup_kind, lower(l.name), 0
+FROM languages l
+UNION ALL
+SELECT l.language_id, 'alias'::language_lookup_kind, lower(a.value), a.position
+FROM languages l,
+  unnest(l.aliases) WITH ORDINALITY AS a(value, position)
+ON CONFLICT DO NOTHING;
+
+-- name: GetLanguagesByExtension :many
+SELECT l.*
+FROM language_lookups k
+  JOIN languages l ON l.language_id = k.language_id
+WHERE k.kind = 'extension'
+  AND lower(k.value) = lower(@extension::text)
+ORDER BY k.position,
+  l.name;
+
+-- name: GetLanguagesByFilename :many
+SELECT l.*
+FROM language_lookups k
+  JOIN languages l ON l.language_id = k.language_id
+WHERE k.kind = 'filename'
+  AND k.value = @filename::text
+ORDER BY l.name;
+
+-- name: GetLanguagesByInterpreter :many
+SELECT l.*
+FROM language_lookups k
+  JOIN languages l ON l.language_id = k.language_id
+WHERE k.kind = 'interpreter'
+  AND k.value = @interpreter::text
+ORDER BY l.name;
+
+-- name: GetLanguageByAlias :one
+SELECT l.*
+FROM language_lookups k
+  JOIN languages l ON l.language_id = k.language_id
+WHERE k.kind = 'alias'
+  AND k.value = lower(@alias::text)
+ORDER BY k.position,
+  l.name
+LIMIT 1;
+
+-- name: ResolveLanguageParents :exec
+UPDATE languages c
+SET parent_id = (
+    SELECT p.language_id
+    FROM languages p
+    WHERE p.name = c."group"
+    LIMIT 1
+  )
+WHERE c.parent_id IS DISTINCT FROM (
+    SELECT p.language_id
+    FROM languages p
+    WHERE p.name = c."group"
+    LIMIT 1
+  );
+
+-- name: GetUnresolvedLanguageGroups :many
+SELECT name,
+  "group"
+FROM languages
+WHERE "group" IS NOT NULL
+  AND parent_id IS NULL
+ORDER BY name;
+
+-- name: GetLanguageChildren :many
+SELECT *
+FROM languages
+WHERE parent_id = @language_id::int
+ORDER BY name;
+
+-- name: GetLanguageRoot :one
+WITH RECURSIVE ancestors AS (
+  SELECT l.language_id,
+    l.parent_id,
+    ARRAY [l.language_id] AS path
+  FROM languages l
+  WHERE l.language_id = @language_id::int
+  UNION ALL
+  SELECT p.language_id,
+    p.parent_id,
+    a.path || p.language_id
+  FROM ancestors a
+    JOIN languages p ON p.language_id = a.parent_id
+  WHERE NOT p.language_id = ANY(a.path)
+)
+SELECT l.*
+FROM ancestors a
+  JOIN languages l ON l.language_id = a.language_id
+ORDER BY cardinality(a.path) DESC
+LIMIT 1;
+
+-- name: DeleteHeuristicDisambiguations :exec
+DELETE FROM heuristic_disambiguations;
+
+-- name: DeleteHeuristicNamedPatterns :exec
+DELETE FROM heuristic_named_patterns;
+
+-- name: CreateHeuristicDisambiguation :one
+INSERT INTO heuristic_disambiguations (position, extensions)
+VALUES ($1, $2)
+RETURNING id;
+
+-- name: CreateHeuristicRule :one
+INSERT INTO heuristic_rules (
+    disambiguation_id,
+    parent_rule_id,
+    position,
+    languages,
+    pattern,
+    negative_pattern,
+    named_pattern
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id;
+
+-- name: CreateHeuristicNamedPattern :exec
+INSERT INTO heuristic_named_patterns (name, pattern)
+VALUES ($1, $2);
+
+-- name: GetHeuristicDisambiguations :many
+SELECT *
+FROM heuristic_disambiguations
+ORDER BY position;
+
+-- name: GetHeuristicRules :many
+SELECT *
+FROM heuristic_rules
+ORDER BY disambiguation_id,
+  parent_rule_id NULLS FIRST,
+  position;
+
+-- name: GetHeuristicNamedPatterns :many
+SELECT *
+FROM heuristic_named_patterns
+ORDER BY name;
+
+-- name: DeletePathRules :exec
+DELETE FROM path_rules;
+
+-- name: DeleteGeneratedExtensions :exec
+DELETE FROM generated_extensions;
+
+-- name: DeleteGeneratedContentRules :exec
+DELETE FROM generated_content_rules;
+
+-- name: CreatePathRules :exec
+INSERT INTO path_rules (kind, position, pattern)
+SELECT @kind::path_rule_kind,
+  p.position - 1,
+  p.pattern
+FROM unnest(@patterns::text []) WITH ORDINALITY AS p(pattern, position);
+
+-- name: CreateGeneratedExtensions :exec
+INSERT INTO generated_extensions (extension)
+SELECT unnest(@extensions::text [])
+ON CONFLICT DO NOTHING;
+
+-- name: CreateGeneratedContentRule :exec
+INSERT INTO generated_content_rules (
+    name,
+    position,
+    extensions,
+    pattern,
+    lines,
+    min_average_line_length
+  )
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetPathRules :many
+SELECT *
+FROM path_rules
+ORDER BY kind,
+  position;
+
+-- name: GetGeneratedExtensions :many
+SELECT extension
+FROM generated_extensions
+ORDER BY extension;
+
+-- name: GetGeneratedContentRules :many
+SELECT *
+FROM generated_content_rules
+ORDER BY position;
+
+-- name: DeleteGrammars :exec
+DELETE FROM grammars;
+
+-- name: CreateGrammars :exec
+INSERT INTO grammars (scope, repository)
+SELECT unnest(@scopes::text []),
+  unnest(@repositories::text []);
+
+-- name: GetLanguagesWithUnknownScope :many
+SELECT l.language_id,
+  l.name,
+  l.tm_scope
+FROM languages l
+WHERE l.tm_scope IS NOT NULL
+  AND l.tm_scope <> 'none'
+  AND NOT EXISTS (
+    SELECT 1
+    FROM grammars g
+    WHERE g.scope = l.tm_scope
+  )
+ORDER BY l.name;
+
+-- name: GetLanguageGrammars :many
+SELECT l.language_id,
+  l.name,
+  l.tm_scope,
+  g.repository
+FROM languages l
+  LEFT JOIN grammars g ON g.scope = l.tm_scope
+ORDER BY l.name;
+
+-- name: UpsertLanguageSyntax :exec
+INSERT INTO language_syntax (
+    language_id,
+    catalog,
+    catalog_name,
+    line_comments,
+    block_comment_starts,
+    block_comment_ends,
+    nested_block_comments,
+    quote_starts,
+    quote_ends
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (language_id) DO
+UPDATE
+SET catalog = EXCLUDED.catalog,
+  catalog_name = EXCLUDED.catalog_name,
+  line_comments = EXCLUDED.line_comments,
+  block_comment_starts = EXCLUDED.block_comment_starts,
+  block_comment_ends = EXCLUDED.block_comment_ends,
+  nested_block_comments = EXCLUDED.nested_block_comments,
+  quote_starts = EXCLUDED.quote_starts,
+  quote_ends = EXCLUDED.quote_ends,
+  imported_at = now();
+
+-- name: GetLanguageSyntax :many
+SELECT *
+FROM language_syntax
+ORDER BY language_id;
+
+-- name: CreateLanguageStaging :exec
+CREATE TEMPORARY TABLE languages_staging ON COMMIT DROP AS
+SELECT name,
+  fs_name,
+  "type",
+  aliases,
+  ace_mode,
+  codemirror_mode,
+  codemirror_mime_type,
+  wrap,
+  extensions,
+  filenames,
+  interpreters,
+  language_id,
+  color,
+  tm_scope,
+  "group",
+  ingest_run_id,
+  custom,
+  overlay
+FROM languages WITH NO DATA;
+
+-- name: MergeLanguageStaging :exec
+INSERT INTO languages (
+    name,
+    fs_name,
+    "type",
+    aliases,
+    ace_mode,
+    codemirror_mode,
+    codemirror_mime_type,
+    wrap,
+    extensions,
+    filenames,
+    interpreters,
+    language_id,
+    color,
+    tm_scope,
+    "group",
+    ingest_run_id,
+    custom,
+    overlay
+  )
+SELECT name,
+  fs_name,
+  "type",
+  aliases,
+  ace_mode,
+  codemirror_mode,
+  codemirror_mime_type,
+  wrap,
+  extensions,
+  filenames,
+  interpreters,
+  language_id,
+  color,
+  tm_scope,
+  "group",
+  ingest_run_id,
+  custom,
+  overlay
+FROM languages_staging ON CONFLICT (language_id) DO
+UPDATE
+SET name = EXCLUDED.name,
+  fs_name = EXCLUDED.fs_name,
+  "type" = EXCLUDED."type",
+  aliases = EXCLUDED.aliases,
+  ace_mode = EXCLUDED.ace_mode,
+  codemirror_mode = EXCLUDED.codemirror_mode,
+  codemirror_mime_type = EXCLUDED.codemirror_mime_type,
+  wrap = EXCLUDED.wrap,
+  extensions = EXCLUDED.extensions,
+  filenames = EXCLUDED.filenames,
+  interpreters = EXCLUDED.interpreters,
+  color = EXCLUDED.color,
+  tm_scope = EXCLUDED.tm_scope,
+  "group" = EXCLUDED."group",
+  ingest_run_id = EXCLUDED.ingest_run_id,
+  custom = EXCLUDED.custom,
+  overlay = EXCLUDED.overlay;